@@ -0,0 +1,119 @@
+package elevation
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// read the first band of an ESRI BIL raster in EPSG:4326 described by its .hdr header
+//
+// supports 8, 16 and 32 bit integers and 32 and 64 bit floats in either byte order
+func ReadBIL(r io.Reader, hdr io.Reader) (*Grid, error) {
+	header := map[string]string{}
+	scanner := bufio.NewScanner(hdr)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 {
+			header[strings.ToUpper(fields[0])] = fields[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// numeric header value, def when missing. a NaN def makes the value required
+	number := func(key string, def float64) (float64, error) {
+		s, ok := header[key]
+		if !ok {
+			if math.IsNaN(def) {
+				return 0, fmt.Errorf("missing %s in header", key)
+			}
+			return def, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s in header: %v", key, err)
+		}
+		return v, nil
+	}
+	var err error
+	get := func(key string, def float64) float64 {
+		if err != nil {
+			return 0
+		}
+		var v float64
+		v, err = number(key, def)
+		return v
+	}
+
+	if layout, ok := header["LAYOUT"]; ok && !strings.EqualFold(layout, "BIL") {
+		return nil, fmt.Errorf("unsupported layout: %s", layout)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if b := strings.ToUpper(header["BYTEORDER"]); b == "M" || b == "MOTOROLA" {
+		order = binary.BigEndian
+	}
+	rows, cols := int(get("NROWS", math.NaN())), int(get("NCOLS", math.NaN()))
+	bits, bands, skip := int(get("NBITS", 8)), int(get("NBANDS", 1)), int(get("SKIPBYTES", 0))
+	dx := get("XDIM", math.NaN())
+	dy := get("YDIM", dx)
+	west, north := get("ULXMAP", math.NaN()), get("ULYMAP", math.NaN())
+	bandRowBytes := get("BANDROWBYTES", float64(cols*bits/8))
+	totalRowBytes := get("TOTALROWBYTES", bandRowBytes*float64(bands))
+	if err != nil {
+		return nil, err
+	}
+	if rows < 1 || cols < 1 || bands < 1 || bits%8 != 0 || int(totalRowBytes) < cols*bits/8 {
+		return nil, fmt.Errorf("invalid size: %dx%d with %d bands of %d bits", cols, rows, bands, bits)
+	}
+
+	pixelType := strings.ToUpper(header["PIXELTYPE"])
+	var decode func(b []byte) float64
+	switch {
+	case pixelType == "FLOAT" && bits == 32:
+		decode = func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }
+	case pixelType == "FLOAT" && bits == 64:
+		decode = func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }
+	case pixelType == "SIGNEDINT" && bits == 8:
+		decode = func(b []byte) float64 { return float64(int8(b[0])) }
+	case pixelType == "SIGNEDINT" && bits == 16:
+		decode = func(b []byte) float64 { return float64(int16(order.Uint16(b))) }
+	case pixelType == "SIGNEDINT" && bits == 32:
+		decode = func(b []byte) float64 { return float64(int32(order.Uint32(b))) }
+	case pixelType != "FLOAT" && bits == 8:
+		decode = func(b []byte) float64 { return float64(b[0]) }
+	case pixelType != "FLOAT" && bits == 16:
+		decode = func(b []byte) float64 { return float64(order.Uint16(b)) }
+	case pixelType != "FLOAT" && bits == 32:
+		decode = func(b []byte) float64 { return float64(order.Uint32(b)) }
+	default:
+		return nil, fmt.Errorf("unsupported pixel type: %s with %d bits", pixelType, bits)
+	}
+	noData, noDataErr := number("NODATA", math.NaN())
+	hasNoData := noDataErr == nil
+
+	if _, err := io.CopyN(io.Discard, r, int64(skip)); err != nil {
+		return nil, fmt.Errorf("unable to skip %d bytes: %v", skip, err)
+	}
+	size := bits / 8
+	data := make([]float64, rows*cols)
+	row := make([]byte, int(totalRowBytes))
+	for i := range rows {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, fmt.Errorf("row %d: %v", i, err)
+		}
+		// the first band comes first in every row
+		for j := range cols {
+			v := decode(row[j*size:])
+			if hasNoData && v == noData {
+				v = math.NaN()
+			}
+			data[i*cols+j] = v
+		}
+	}
+	return newGridFromRaster(north, west, dx, dy, cols, rows, data)
+}
@@ -0,0 +1,166 @@
+package elevation
+
+// cycling style climb category
+type ClimbCategory string
+
+// cycling style climb categories
+//
+// categories are assigned from the climb score (length in meters * average grade in percent)
+const (
+	Uncategorized ClimbCategory = ""
+	Category4     ClimbCategory = "4"
+	Category3     ClimbCategory = "3"
+	Category2     ClimbCategory = "2"
+	Category1     ClimbCategory = "1"
+	HorsCategorie ClimbCategory = "HC"
+)
+
+// minimum score needed for each category
+const (
+	category4Score     = 8000
+	category3Score     = 16000
+	category2Score     = 32000
+	category1Score     = 64000
+	horsCategorieScore = 80000
+)
+
+// a contiguous climb along a profile
+//
+// distances are in meters, grades are in percent
+type Climb struct {
+	StartDistance float64       `json:"start_distance"`
+	EndDistance   float64       `json:"end_distance"`
+	Length        float64       `json:"length"`
+	Start         ProfilePoint  `json:"start"`
+	End           ProfilePoint  `json:"end"`
+	ElevationGain float64       `json:"elevation_gain"`
+	AverageGrade  float64       `json:"average_grade"`
+	MaxGrade      float64       `json:"max_grade"`
+	Score         float64       `json:"score"`
+	Category      ClimbCategory `json:"category"`
+}
+
+// thresholds used when detecting climbs
+type ClimbOptions struct {
+	// minimum elevation gain (m) for a climb to be reported
+	MinGain float64
+	// minimum length (m) for a climb to be reported
+	MinLength float64
+	// minimum average grade (%) for a climb to be reported
+	MinGrade float64
+	// how far (m) the route may drop below the highest point before the climb is considered over
+	MaxDescent float64
+	// distance (m) over which the max grade is measured
+	GradientWindow float64
+}
+
+// sensible defaults for road cycling
+var DefaultClimbOptions = ClimbOptions{
+	MinGain:        20,
+	MinLength:      500,
+	MinGrade:       3,
+	MaxDescent:     10,
+	GradientWindow: 100,
+}
+
+// return the category for a given climb length (m) and average grade (%)
+func CategorizeClimb(length float64, grade float64) ClimbCategory {
+	score := length * grade
+	switch {
+	case score >= horsCategorieScore:
+		return HorsCategorie
+	case score >= category1Score:
+		return Category1
+	case score >= category2Score:
+		return Category2
+	case score >= category3Score:
+		return Category3
+	case score >= category4Score:
+		return Category4
+	default:
+		return Uncategorized
+	}
+}
+
+// find all the climbs in the profile
+//
+// the profile is expected to be ordered by distance
+func DetectClimbs(profile []ProfilePoint, opts ClimbOptions) []Climb {
+	climbs := []Climb{}
+	if len(profile) < 2 {
+		return climbs
+	}
+	emit := func(start int, top int) {
+		if top <= start {
+			return
+		}
+		if c, ok := newClimb(profile[start:top+1], opts); ok {
+			climbs = append(climbs, c)
+		}
+	}
+
+	start, top := 0, 0
+	for i := 1; i < len(profile); i++ {
+		elev := profile[i].Elevation
+		if elev > profile[top].Elevation {
+			top = i
+		} else if profile[top].Elevation-elev > opts.MaxDescent || elev < profile[start].Elevation {
+			emit(start, top)
+			start, top = i, i
+		}
+	}
+	emit(start, top)
+	return climbs
+}
+
+// build a climb from the points between the bottom and the top
+//
+// returns false if the climb does not meet the thresholds in opts
+func newClimb(points []ProfilePoint, opts ClimbOptions) (Climb, bool) {
+	first, last := points[0], points[len(points)-1]
+	length := last.Distance - first.Distance
+	gain := last.Elevation - first.Elevation
+	if length <= 0 || length < opts.MinLength || gain < opts.MinGain {
+		return Climb{}, false
+	}
+	grade := gain / length * 100
+	if grade < opts.MinGrade {
+		return Climb{}, false
+	}
+	return Climb{
+		StartDistance: first.Distance,
+		EndDistance:   last.Distance,
+		Length:        length,
+		Start:         first,
+		End:           last,
+		ElevationGain: gain,
+		AverageGrade:  grade,
+		MaxGrade:      maxGrade(points, opts.GradientWindow, grade),
+		Score:         length * grade,
+		Category:      CategorizeClimb(length, grade),
+	}, true
+}
+
+// steepest grade (%) measured over window meters
+//
+// falls back to the average grade when the points are shorter than the window
+func maxGrade(points []ProfilePoint, window float64, average float64) float64 {
+	best := average
+	k := 0
+	for j := range points {
+		if k < j {
+			k = j
+		}
+		for k < len(points)-1 && points[k].Distance-points[j].Distance < window {
+			k++
+		}
+		d := points[k].Distance - points[j].Distance
+		if d < window || d <= 0 {
+			break
+		}
+		if g := (points[k].Elevation - points[j].Elevation) / d * 100; g > best {
+			best = g
+		}
+	}
+	return best
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"elevation/pkg/service"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func climbs() {
+	climbsCmd := flag.NewFlagSet("climbs", flag.ExitOnError)
+	climbsCmd.Usage = func() {
+		fmt.Printf("usage: %s climbs [options] [DB FILE] [ROUTE FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("route file is a csv of latitude,longitude pairs (default: stdin)")
+		fmt.Println("")
+		fmt.Println("options:")
+		climbsCmd.PrintDefaults()
+	}
+	var interval float64
+	climbsCmd.Float64Var(&interval, "i", 30, "distance in meters between sampled points")
+	var interpolation string
+	climbsCmd.StringVar(&interpolation, "interpolation", service.Bilinear, "interpolation method (options: nearest, bilinear, bicubic)")
+	opts := elevation.DefaultClimbOptions
+	climbsCmd.Float64Var(&opts.MinGain, "min-gain", opts.MinGain, "minimum elevation gain in meters")
+	climbsCmd.Float64Var(&opts.MinLength, "min-length", opts.MinLength, "minimum climb length in meters")
+	climbsCmd.Float64Var(&opts.MinGrade, "min-grade", opts.MinGrade, "minimum average grade in percent")
+	climbsCmd.Float64Var(&opts.MaxDescent, "max-descent", opts.MaxDescent, "descent in meters that ends a climb")
+
+	err := climbsCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if climbsCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+
+	var in io.Reader = os.Stdin
+	if climbsCmd.NArg() > 1 && climbsCmd.Arg(1) != "-" {
+		f, err := os.Open(climbsCmd.Arg(1))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: could not open file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	path, err := readPath(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(climbsCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := s.GetClimbs(context.TODO(), path, interval, 0, service.InterpolationMethod(interpolation), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
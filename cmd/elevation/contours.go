@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func contours() {
+	contoursCmd := flag.NewFlagSet("contours", flag.ExitOnError)
+	contoursCmd.Usage = func() {
+		fmt.Printf("usage: %s contours [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("writes contour lines for the bounding box as GeoJSON")
+		fmt.Println("")
+		fmt.Println("options:")
+		contoursCmd.PrintDefaults()
+	}
+	var bboxStr string
+	contoursCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	contoursCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var interval float64
+	contoursCmd.Float64Var(&interval, "i", 10, "contour interval in meters")
+	var index float64
+	contoursCmd.Float64Var(&index, "index", 50, "index contour interval in meters (0 to disable)")
+
+	err := contoursCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if contoursCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(contoursCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	lines, err := s.GetContours(context.TODO(), bbox, 0, interval, index)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := elevation.WriteGeoJSON(out, elevation.ContoursToGeoJSON(lines)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
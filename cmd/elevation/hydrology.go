@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func hydrology() {
+	hydrologyCmd := flag.NewFlagSet("hydrology", flag.ExitOnError)
+	hydrologyCmd.Usage = func() {
+		fmt.Printf("usage: %s hydrology [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("fills depressions and computes D8 flow direction, flow accumulation and streams")
+		fmt.Println("")
+		fmt.Println("options:")
+		hydrologyCmd.PrintDefaults()
+	}
+	var bboxStr string
+	hydrologyCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	hydrologyCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var raster string
+	hydrologyCmd.StringVar(&raster, "r", "accumulation", "output (options: filled, direction, accumulation, streams)")
+	var format string
+	hydrologyCmd.StringVar(&format, "f", "tiff", "raster format (options: tiff, asc). streams are always GeoJSON")
+	var threshold float64
+	hydrologyCmd.Float64Var(&threshold, "t", 1000, "number of cells that must drain through a cell for it to be a stream")
+
+	err := hydrologyCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if hydrologyCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(hydrologyCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	h, err := s.GetHydrology(context.TODO(), bbox, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	var g *elevation.Grid
+	switch raster {
+	case "filled":
+		g = h.Filled
+	case "direction":
+		g = h.Direction
+	case "accumulation":
+		g = h.Accumulation
+	case "streams":
+		if err := elevation.WriteGeoJSON(out, elevation.StreamsToGeoJSON(h.Streams(threshold))); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "invalid raster: %s\n", raster)
+		os.Exit(1)
+	}
+	if err := writeRaster(out, g, format); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"elevation"
+	"elevation/pkg/db"
+	"elevation/pkg/service"
+	encodingcsv "encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// open the sqlite db at fpath (read only) and wrap it in a service
+func openService(fpath string) (*service.ElevationService, error) {
+	if _, err := os.Stat(fpath); err != nil {
+		return nil, fmt.Errorf("could not open file: %v", err)
+	}
+	d, err := db.NewElevationDB(fpath, true)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewElevationService(d), nil
+}
+
+// name of the elevation layer in geopackage outputs
+const geoPackageLayer = "elevation"
+
+// read a dem into a grid
+//
+// the format is detected from the extension of fpath when empty (hgt for stdin). hgt files are
+// georeferenced by tileName (default: the file name), which also names the product when it is a
+// download name such as NASADEM_HGT_n00e006.zip. bil files need their .hdr next to them
+func readDEM(in io.Reader, fpath string, format string, tileName string) (*elevation.Grid, elevation.Product, error) {
+	var f elevation.DEMFormat
+	var err error
+	switch {
+	case format != "":
+		f, err = elevation.ParseDEMFormat(format)
+	case fpath == "":
+		f = elevation.HGTFormat
+	default:
+		f, err = elevation.DEMFormatFromPath(fpath)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var grid *elevation.Grid
+	switch f {
+	case elevation.HGTFormat:
+		if tileName == "" {
+			tileName = filepath.Base(fpath)
+		}
+		tile, err := elevation.ParseTile(tileName)
+		if err != nil {
+			return nil, "", err
+		}
+		grid, err := elevation.ReadHGT(in, tile.Latitude, tile.Longitude)
+		if err != nil {
+			return nil, "", err
+		}
+		if tile.Spacing != 0 && tile.Spacing != grid.Spacing {
+			return nil, "", fmt.Errorf("%s is %s but the hgt has a spacing of %g arc-seconds", tileName, tile.Product, float64(grid.Spacing)*3600)
+		}
+		return grid, tile.Product, nil
+	case elevation.ASCIIGridFormat:
+		grid, err = elevation.ReadASCIIGrid(in)
+	case elevation.BILFormat:
+		if fpath == "" {
+			return nil, "", fmt.Errorf("bil needs its .hdr file and cannot be read from stdin")
+		}
+		base := strings.TrimSuffix(fpath, filepath.Ext(fpath))
+		hdr, err := os.Open(base + ".hdr")
+		if os.IsNotExist(err) {
+			hdr, err = os.Open(base + ".HDR")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		defer hdr.Close()
+		grid, err = elevation.ReadBIL(in, hdr)
+	default:
+		grid, err = elevation.ReadGeoTIFF(in)
+	}
+	return grid, "", err
+}
+
+// read a path of lat,lng pairs in csv form
+//
+// a header row is skipped if present
+func readPath(r io.Reader) ([]elevation.Coordinate, error) {
+	reader := encodingcsv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	path := []elevation.Coordinate{}
+	for i := 0; ; i++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) < 2 {
+			return nil, fmt.Errorf("line %d: expected latitude,longitude", i+1)
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(row[0]), 64)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: unable to parse latitude: %v", i+1, err)
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: unable to parse longitude: %v", i+1, err)
+		}
+		path = append(path, elevation.Coordinate{Latitude: lat, Longitude: lng})
+	}
+	return path, nil
+}
+
+// open the output file, or stdout if output is empty or "-"
+func createOutput(output string) (io.WriteCloser, error) {
+	if output == "" || output == "-" {
+		return os.Stdout, nil
+	}
+	return os.Create(output)
+}
+
+// write the grid to w in format (tiff or asc)
+func writeRaster(w io.Writer, g *elevation.Grid, format string) error {
+	switch format {
+	case "tiff":
+		return elevation.GridToGeoTIFF(w, g)
+	case "asc":
+		return elevation.GridToASCII(w, g)
+	default:
+		return fmt.Errorf("invalid format: %s", format)
+	}
+}
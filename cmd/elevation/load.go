@@ -4,12 +4,11 @@ import (
 	"context"
 	"elevation"
 	"elevation/pkg/db"
+	"elevation/pkg/tiles"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"strings"
 )
 
 func load() {
@@ -22,11 +21,15 @@ func load() {
 
 	}
 	var tileName string
-	loadCmd.StringVar(&tileName, "t", "", "tile name (e.g. 'N00E006') (default: use passed file basename. required if reading from stdin)")
+	loadCmd.StringVar(&tileName, "t", "", "hgt tile name (e.g. 'N00E006', 'N00E006.SRTMGL3.hgt' or 'NASADEM_HGT_n00e006.zip') (default: use passed file basename. required if reading hgt from stdin)")
+	var inputFormat string
+	loadCmd.StringVar(&inputFormat, "i", "", "input format (options: hgt, asc, bil, tiff) (default: detect from the file extension, hgt for stdin)")
 	var output string
 	loadCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
 	var format string
-	loadCmd.StringVar(&format, "f", "csv", "output format (options: sqlite, csv)")
+	loadCmd.StringVar(&format, "f", "csv", "output format (options: sqlite, csv, gpkg)")
+	var tileFormat string
+	loadCmd.StringVar(&tileFormat, "tile-format", string(tiles.CoveragePNG), "gpkg tile encoding (options: png, tiff)")
 
 	err := loadCmd.Parse(os.Args[2:])
 	if err != nil {
@@ -40,17 +43,18 @@ func load() {
 	}
 
 	var in io.Reader
+	var fpath string
 	useStdin := loadCmd.NArg() == 0 || (loadCmd.NArg() == 1 && loadCmd.Arg(0) == "-")
 	oneArg := loadCmd.NArg() == 1 && !(loadCmd.Arg(0) == "-")
 
 	if useStdin {
-		if tileName == "" {
+		if tileName == "" && (inputFormat == "" || inputFormat == string(elevation.HGTFormat)) {
 			fmt.Fprintln(os.Stderr, "error: must include tileName when passing to stdin")
 			os.Exit(1)
 		}
 		in = os.Stdin
 	} else if oneArg {
-		fpath := loadCmd.Arg(0)
+		fpath = loadCmd.Arg(0)
 		file, err := os.Open(fpath)
 		if err != nil {
 			fmt.Fprintf(os.Stdout, "error: could not open file: %v\n", err)
@@ -58,28 +62,20 @@ func load() {
 		}
 		defer file.Close()
 		in = file
-
-		if tileName == "" {
-			tileName = strings.TrimSuffix(filepath.Base(fpath), filepath.Ext(fpath))
-		}
-	}
-
-	lat, lng, err := elevation.ParseTileName(tileName)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
 	}
 
-	records, err := elevation.ProcessHGT(in, lat, lng)
+	grid, product, err := readDEM(in, fpath, inputFormat, tileName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	records := elevation.GridRecords(grid)
 
 	var out io.Writer
 	if output == "" || output == "-" {
 		out = os.Stdout
-	} else {
+	} else if format == csv {
+		// sqlite and gpkg open the output themselves, creating it here would truncate what is already there
 		f, err := os.Create(output)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -105,10 +101,28 @@ func load() {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
+		if err = db.CreateTiles(context.TODO(), elevation.GridTiles(grid, product)); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
 		if err = db.CreateFinalTable(context.TODO()); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
+	case "gpkg":
+		gp, err := tiles.OpenGeoPackage(output, geoPackageLayer, grid.Spacing, tiles.CoverageEncoding(tileFormat))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if err = gp.WriteGrid(context.TODO(), grid); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if err = gp.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		fmt.Fprintf(os.Stderr, "invalid format: %s\n", format)
 		os.Exit(1)
@@ -4,12 +4,12 @@ import (
 	"context"
 	"elevation"
 	"elevation/pkg/db"
+	"elevation/pkg/tiles"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 func loadMany() {
@@ -17,7 +17,7 @@ func loadMany() {
 	loadCmd.Usage = func() {
 		fmt.Printf("usage: %s load-many [options] [FILES...]\n", os.Args[0])
 		fmt.Println("")
-		fmt.Println("assumes files are names so that tile name can be extracted from it")
+		fmt.Println("assumes hgt files are named so that tile name can be extracted from it")
 		fmt.Println("")
 		fmt.Println("options:")
 		loadCmd.PrintDefaults()
@@ -27,7 +27,11 @@ func loadMany() {
 	var output string
 	loadCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
 	var format string
-	loadCmd.StringVar(&format, "f", "csv", "output format (options: sqlite, csv)")
+	loadCmd.StringVar(&format, "f", "csv", "output format (options: sqlite, csv, gpkg)")
+	var inputFormat string
+	loadCmd.StringVar(&inputFormat, "i", "", "input format (options: hgt, asc, bil, tiff) (default: detect from each file extension)")
+	var tileFormat string
+	loadCmd.StringVar(&tileFormat, "tile-format", string(tiles.CoveragePNG), "gpkg tile encoding (options: png, tiff)")
 
 	err := loadCmd.Parse(os.Args[2:])
 	if err != nil {
@@ -60,7 +64,8 @@ func loadMany() {
 	var out io.Writer
 	if output == "" || output == "-" {
 		out = os.Stdout
-	} else {
+	} else if format == csv {
+		// sqlite and gpkg open the output themselves, creating it here would truncate what is already there
 		f, err := os.Create(output)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -70,6 +75,8 @@ func loadMany() {
 	}
 
 	var d db.ElevationDB
+	// opened with the spacing of the first file
+	var gp *tiles.GeoPackage
 	if format == sqlite {
 		d, err = db.NewElevationDB(output, false)
 		if err != nil {
@@ -80,22 +87,18 @@ func loadMany() {
 
 	// process all the files
 	for i, fpath := range files {
-		tileName := strings.TrimSuffix(filepath.Base(fpath), filepath.Ext(fpath))
-		lat, lng, err := elevation.ParseTileName(tileName)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
-		}
 		f, err := os.Open(fpath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
-		records, err := elevation.ProcessHGT(f, lat, lng)
+		grid, product, err := readDEM(f, fpath, inputFormat, "")
+		f.Close()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", fpath, err)
 			os.Exit(1)
 		}
+		records := elevation.GridRecords(grid)
 
 		switch format {
 		case "csv":
@@ -110,6 +113,22 @@ func loadMany() {
 				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				os.Exit(1)
 			}
+			if err = d.CreateTiles(context.TODO(), elevation.GridTiles(grid, product)); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+		case "gpkg":
+			if gp == nil {
+				gp, err = tiles.OpenGeoPackage(output, geoPackageLayer, grid.Spacing, tiles.CoverageEncoding(tileFormat))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if err = gp.WriteGrid(context.TODO(), grid); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", fpath, err)
+				os.Exit(1)
+			}
 
 		default:
 			fmt.Fprintf(os.Stderr, "invalid format: %s\n", format)
@@ -117,6 +136,12 @@ func loadMany() {
 		}
 	}
 
+	if gp != nil {
+		if err = gp.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	if format == sqlite {
 		fmt.Println("records created")
 		if err = d.CreateFinalTable(context.TODO()); err != nil {
@@ -13,6 +13,17 @@ func usage() {
 	fmt.Println("serve - serve the data in the sqlite db over http")
 	fmt.Println("load - load data from htg")
 	fmt.Println("load-many - load multiple files and/or match on glob")
+	fmt.Println("climbs - detect and categorize climbs along a route")
+	fmt.Println("slope - export a slope or aspect raster for a bounding box")
+	fmt.Println("render - render images (hillshade) for a bounding box")
+	fmt.Println("contours - generate contour lines for a bounding box as GeoJSON")
+	fmt.Println("viewshed - compute the area visible from an observer")
+	fmt.Println("hydrology - flow direction, flow accumulation and streams for a bounding box")
+	fmt.Println("watershed - delineate the catchment upstream of a pour point")
+	fmt.Println("terrain - ruggedness, position and curvature indexes for a bounding box")
+	fmt.Println("peaks - find summits with their prominence and isolation")
+	fmt.Println("mesh - export a bounding box as a 3d mesh (stl, obj, gltf)")
+	fmt.Println("tiles - pre-render a tile pyramid into mbtiles or pmtiles")
 	fmt.Println("")
 	fmt.Println("options:")
 	flag.PrintDefaults()
@@ -21,16 +32,17 @@ func usage() {
 const (
 	sqlite = "sqlite"
 	csv    = "csv"
+	gpkg   = "gpkg"
 )
 
 func validateOutputFormats(output string, format string) error {
 	switch format {
 	case csv:
 		return nil
-	case sqlite:
+	case sqlite, gpkg:
 		// stdout
 		if output == "" || output == "-" {
-			return fmt.Errorf("cannot use sqlite format and stdout together")
+			return fmt.Errorf("cannot use %s format and stdout together", format)
 		}
 		return nil
 	default:
@@ -51,6 +63,28 @@ func main() {
 		loadMany()
 	case "serve":
 		serve()
+	case "climbs":
+		climbs()
+	case "slope":
+		slope()
+	case "render":
+		render()
+	case "contours":
+		contours()
+	case "viewshed":
+		viewshed()
+	case "hydrology":
+		hydrology()
+	case "watershed":
+		watershed()
+	case "terrain":
+		terrain()
+	case "peaks":
+		peaks()
+	case "mesh":
+		mesh()
+	case "tiles":
+		tilePyramid()
 	default:
 		fmt.Fprintf(os.Stderr, "invalid command: %s\n", os.Args[1])
 		os.Exit(1)
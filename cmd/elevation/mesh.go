@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func mesh() {
+	meshCmd := flag.NewFlagSet("mesh", flag.ExitOnError)
+	meshCmd.Usage = func() {
+		fmt.Printf("usage: %s mesh [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("writes the terrain in the bounding box as a triangulated mesh in local metric coordinates")
+		fmt.Println("")
+		fmt.Println("options:")
+		meshCmd.PrintDefaults()
+	}
+	var bboxStr string
+	meshCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	meshCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var format string
+	meshCmd.StringVar(&format, "f", "stl", "mesh format (options: stl, obj, gltf)")
+	var resolution float64
+	meshCmd.Float64Var(&resolution, "r", 0, "resolution in meters (default: the resolution of the data)")
+	opts := elevation.DefaultMeshOptions
+	meshCmd.Float64Var(&opts.Exaggeration, "z", opts.Exaggeration, "vertical exaggeration")
+	meshCmd.Float64Var(&opts.Base, "base", opts.Base, "thickness in meters of a solid base below the lowest point, for printing")
+
+	err := meshCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if meshCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var write func(io.Writer, elevation.Mesh) error
+	switch format {
+	case "stl":
+		write = elevation.MeshToSTL
+	case "obj":
+		write = elevation.MeshToOBJ
+	case "gltf":
+		write = elevation.MeshToGLTF
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid format: %s\n", format)
+		os.Exit(1)
+	}
+
+	s, err := openService(meshCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	m, err := s.GetMesh(context.TODO(), bbox, 0, resolution, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := write(out, m); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
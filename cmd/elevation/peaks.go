@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func peaks() {
+	peaksCmd := flag.NewFlagSet("peaks", flag.ExitOnError)
+	peaksCmd.Usage = func() {
+		fmt.Printf("usage: %s peaks [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("writes the peaks in the bounding box, ranked by prominence, as GeoJSON")
+		fmt.Println("")
+		fmt.Println("options:")
+		peaksCmd.PrintDefaults()
+	}
+	var bboxStr string
+	peaksCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	peaksCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var minProminence float64
+	peaksCmd.Float64Var(&minProminence, "p", 30, "minimum prominence in meters")
+
+	err := peaksCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if peaksCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(peaksCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	result, err := s.GetPeaks(context.TODO(), bbox, 0, minProminence)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := elevation.WriteGeoJSON(out, elevation.PeaksToGeoJSON(result)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
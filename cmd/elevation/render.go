@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func renderUsage() {
+	fmt.Printf("usage: %s render TYPE [options] [DB FILE]\n", os.Args[0])
+	fmt.Println("")
+	fmt.Println("types")
+	fmt.Println("hillshade - shaded relief as a grayscale png")
+	fmt.Println("heightmap - elevations as a 16 bit grayscale png with a json sidecar")
+}
+
+func render() {
+	if len(os.Args) < 3 {
+		renderUsage()
+		os.Exit(1)
+	}
+	switch os.Args[2] {
+	case "hillshade":
+		renderHillshade()
+	case "heightmap":
+		renderHeightmap()
+	default:
+		fmt.Fprintf(os.Stderr, "invalid render type: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+}
+
+func renderHillshade() {
+	hillshadeCmd := flag.NewFlagSet("hillshade", flag.ExitOnError)
+	hillshadeCmd.Usage = func() {
+		fmt.Printf("usage: %s render hillshade [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("options:")
+		hillshadeCmd.PrintDefaults()
+	}
+	var bboxStr string
+	hillshadeCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	hillshadeCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	opts := elevation.DefaultHillshadeOptions
+	hillshadeCmd.Float64Var(&opts.Azimuth, "azimuth", opts.Azimuth, "direction of the sun in degrees clockwise from north")
+	hillshadeCmd.Float64Var(&opts.Altitude, "altitude", opts.Altitude, "angle of the sun in degrees above the horizon")
+	hillshadeCmd.Float64Var(&opts.ZFactor, "z", opts.ZFactor, "vertical exaggeration")
+	hillshadeCmd.BoolVar(&opts.MultiDirectional, "multidirectional", opts.MultiDirectional, "blend light from several directions")
+
+	err := hillshadeCmd.Parse(os.Args[3:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if hillshadeCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(hillshadeCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	g, err := s.GetHillshade(context.TODO(), bbox, 0, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := elevation.GridToGrayPNG(out, g); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func renderHeightmap() {
+	heightmapCmd := flag.NewFlagSet("heightmap", flag.ExitOnError)
+	heightmapCmd.Usage = func() {
+		fmt.Printf("usage: %s render heightmap [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("options:")
+		heightmapCmd.PrintDefaults()
+	}
+	var bboxStr string
+	heightmapCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	heightmapCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var sidecar string
+	heightmapCmd.StringVar(&sidecar, "sidecar", "", "file name for the json metadata (default: output with a .json extension, none for stdout)")
+	opts := elevation.DefaultHeightmapOptions
+	heightmapCmd.IntVar(&opts.Width, "width", opts.Width, "width in pixels (default: one pixel per cell)")
+	heightmapCmd.IntVar(&opts.Height, "height", opts.Height, "height in pixels (default: one pixel per cell)")
+	heightmapCmd.Float64Var(&opts.Min, "min", opts.Min, "elevation mapped to 1, 0 is kept for voids (default: lowest elevation)")
+	heightmapCmd.Float64Var(&opts.Max, "max", opts.Max, "elevation mapped to 65535 (default: highest elevation)")
+	var resampling string
+	heightmapCmd.StringVar(&resampling, "resample", string(opts.Resampling), "resampling method (options: nearest, bilinear, bicubic)")
+
+	err := heightmapCmd.Parse(os.Args[3:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if heightmapCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	opts.Resampling = elevation.InterpolationMethod(resampling)
+	if sidecar == "" && output != "" && output != "-" {
+		sidecar = strings.TrimSuffix(output, filepath.Ext(output)) + ".json"
+	}
+
+	s, err := openService(heightmapCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	img, meta, err := s.GetHeightmap(context.TODO(), bbox, 0, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := elevation.HeightmapToPNG(out, img); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if sidecar == "" {
+		return
+	}
+	f, err := os.Create(sidecar)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	if err := elevation.HeightmapMetadataToJSON(f, meta); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"elevation"
 	"elevation/pkg/db"
 	"elevation/pkg/service"
 	"elevation/pkg/server"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func serve() {
@@ -24,6 +27,8 @@ func serve() {
 
 	var verbose bool
 	serveCmd.BoolVar(&verbose, "v", false, "enable verbose")
+	var rampDir string
+	serveCmd.StringVar(&rampDir, "ramps", "", "directory of gdal color-relief .txt files to serve as relief color ramps, named by file")
 
 	err := serveCmd.Parse(os.Args[2:])
 	if err != nil {
@@ -53,10 +58,40 @@ func serve() {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	ramps, err := loadColorRamps(rampDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
 	s := service.NewElevationService(db)
-	err = server.Serve(address, port, s)
+	err = server.Serve(address, port, s, ramps)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
 		os.Exit(1)
 	}
 }
+
+// read every .txt file in dir as a color ramp named after the file
+func loadColorRamps(dir string) (map[string]elevation.ColorRamp, error) {
+	ramps := map[string]elevation.ColorRamp{}
+	if dir == "" {
+		return ramps, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	for _, fpath := range files {
+		f, err := os.Open(fpath)
+		if err != nil {
+			return nil, err
+		}
+		ramp, err := elevation.ParseColorRelief(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fpath, err)
+		}
+		ramps[strings.TrimSuffix(filepath.Base(fpath), ".txt")] = ramp
+	}
+	return ramps, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+)
+
+func slope() {
+	slopeCmd := flag.NewFlagSet("slope", flag.ExitOnError)
+	slopeCmd.Usage = func() {
+		fmt.Printf("usage: %s slope [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("writes a slope or aspect raster for the bounding box as an ESRI ASCII grid")
+		fmt.Println("")
+		fmt.Println("options:")
+		slopeCmd.PrintDefaults()
+	}
+	var bboxStr string
+	slopeCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	slopeCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var alg string
+	slopeCmd.StringVar(&alg, "a", string(elevation.Horn), "algorithm (options: horn, zevenbergen-thorne)")
+	var raster string
+	slopeCmd.StringVar(&raster, "r", "degrees", "raster to output (options: degrees, percent, aspect)")
+
+	err := slopeCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if slopeCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(slopeCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	slopeGrid, aspectGrid, err := s.GetSlopeGrid(context.TODO(), bbox, 0, elevation.SlopeAlgorithm(alg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var g *elevation.Grid
+	switch raster {
+	case "degrees":
+		g = slopeGrid
+	case "percent":
+		g = slopeGrid
+		for i, v := range g.Data {
+			if !math.IsNaN(v) {
+				g.Data[i] = elevation.SlopePercent(v)
+			}
+		}
+	case "aspect":
+		g = aspectGrid
+	default:
+		fmt.Fprintf(os.Stderr, "invalid raster: %s\n", raster)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := elevation.GridToASCII(out, g); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func terrain() {
+	terrainCmd := flag.NewFlagSet("terrain", flag.ExitOnError)
+	terrainCmd.Usage = func() {
+		fmt.Printf("usage: %s terrain [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("writes a terrain index raster for the bounding box, or its statistics with -stats")
+		fmt.Println("")
+		fmt.Println("options:")
+		terrainCmd.PrintDefaults()
+	}
+	var bboxStr string
+	terrainCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	terrainCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var index string
+	terrainCmd.StringVar(&index, "i", string(elevation.TRI), "index (options: tri, tpi, roughness, profile-curvature, plan-curvature)")
+	var format string
+	terrainCmd.StringVar(&format, "f", "tiff", "raster format (options: tiff, asc)")
+	opts := elevation.DefaultTerrainOptions
+	terrainCmd.IntVar(&opts.InnerRadius, "inner", opts.InnerRadius, "inner radius of the tpi annulus in cells")
+	terrainCmd.IntVar(&opts.OuterRadius, "outer", opts.OuterRadius, "outer radius of the tpi annulus in cells")
+	var stats bool
+	terrainCmd.BoolVar(&stats, "stats", false, "output summary statistics as json instead of a raster")
+
+	err := terrainCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if terrainCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(terrainCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	g, err := s.GetTerrainIndex(context.TODO(), bbox, 0, elevation.TerrainIndex(index), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if stats {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(g.Statistics())
+	} else {
+		err = writeRaster(out, g, format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"elevation/pkg/service"
+	"elevation/pkg/tiles"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+func tilePyramid() {
+	tilesCmd := flag.NewFlagSet("tiles", flag.ExitOnError)
+	tilesCmd.Usage = func() {
+		fmt.Printf("usage: %s tiles [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("pre-renders a web mercator tile pyramid for the bounding box into an mbtiles or pmtiles file")
+		fmt.Println("the highest zoom is rendered from the data and each level below is built from the one above it")
+		fmt.Println("tiles already in the output are skipped, so an interrupted run can be resumed")
+		fmt.Println("")
+		fmt.Println("options:")
+		tilesCmd.PrintDefaults()
+	}
+	var bboxStr string
+	tilesCmd.StringVar(&bboxStr, "bbox", "", "bounding box: minLat,minLng,maxLat,maxLng (required)")
+	var output string
+	tilesCmd.StringVar(&output, "o", "", "file name to output, ending in .mbtiles or .pmtiles (required)")
+	var kind string
+	tilesCmd.StringVar(&kind, "t", string(elevation.TerrainRGB), "tile type (options: terrain-rgb, terrarium, hillshade)")
+	var minZoom, maxZoom int
+	tilesCmd.IntVar(&minZoom, "minzoom", 8, "lowest zoom level")
+	tilesCmd.IntVar(&maxZoom, "maxzoom", 12, "highest zoom level")
+	var workers int
+	tilesCmd.IntVar(&workers, "j", runtime.NumCPU(), "number of tiles to render in parallel")
+	var verbose bool
+	tilesCmd.BoolVar(&verbose, "v", false, "print progress")
+
+	err := tilesCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if tilesCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	bbox, err := elevation.ParseBoundingBox(bboxStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if minZoom < 0 || maxZoom > 24 || minZoom > maxZoom {
+		fmt.Fprintf(os.Stderr, "error: invalid zoom range: %d-%d\n", minZoom, maxZoom)
+		os.Exit(1)
+	}
+	if workers < 1 {
+		fmt.Fprintf(os.Stderr, "error: invalid number of workers: %d\n", workers)
+		os.Exit(1)
+	}
+	// pmtiles can only be written once every tile is known, so tiles are staged in an mbtiles file first
+	store := output
+	switch filepath.Ext(output) {
+	case ".mbtiles":
+	case ".pmtiles":
+		store = output + ".partial.mbtiles"
+	default:
+		fmt.Fprintln(os.Stderr, "error: output must end in .mbtiles or .pmtiles")
+		os.Exit(1)
+	}
+
+	s, err := openService(tilesCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var render func(context.Context, elevation.Tile) ([]byte, error)
+	var average func([]color.NRGBA) color.NRGBA
+	metadata := map[string]string{
+		"name":    kind,
+		"format":  "png",
+		"type":    "baselayer",
+		"bounds":  fmt.Sprintf("%f,%f,%f,%f", bbox.MinLongitude, bbox.MinLatitude, bbox.MaxLongitude, bbox.MaxLatitude),
+		"center":  fmt.Sprintf("%f,%f,%d", (bbox.MinLongitude+bbox.MaxLongitude)/2, (bbox.MinLatitude+bbox.MaxLatitude)/2, minZoom),
+		"minzoom": strconv.Itoa(minZoom),
+		"maxzoom": strconv.Itoa(maxZoom),
+	}
+	switch kind {
+	case string(elevation.TerrainRGB), string(elevation.Terrarium):
+		encoding := elevation.TileEncoding(kind)
+		render = func(ctx context.Context, t elevation.Tile) ([]byte, error) {
+			return s.GetElevationTile(ctx, t, encoding, 0)
+		}
+		average = encoding.Average
+		metadata["encoding"] = map[elevation.TileEncoding]string{elevation.TerrainRGB: "mapbox", elevation.Terrarium: "terrarium"}[encoding]
+	case "hillshade":
+		render = func(ctx context.Context, t elevation.Tile) ([]byte, error) {
+			return s.GetHillshadeTile(ctx, t, elevation.DefaultHillshadeOptions, 0)
+		}
+		average = elevation.AverageColor
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid tile type: %s\n", kind)
+		os.Exit(1)
+	}
+
+	m, err := tiles.OpenMBTiles(store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer m.Close()
+	ctx := context.TODO()
+	if err := m.SetMetadata(ctx, metadata); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// only the highest zoom is rendered from the data, each level below is built from its children
+	for z := maxZoom; z >= minZoom; z-- {
+		nw := elevation.TileAt(z, bbox.MaxLatitude, bbox.MinLongitude)
+		se := elevation.TileAt(z, bbox.MinLatitude, bbox.MaxLongitude)
+		queue := make(chan elevation.Tile)
+		var rendered, skipped atomic.Int64
+		var wg sync.WaitGroup
+		var once sync.Once
+		var failed error
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for t := range queue {
+					wrote, err := buildTile(ctx, m, t, z == maxZoom, render, average)
+					if err != nil {
+						once.Do(func() { failed = err })
+						continue
+					}
+					if wrote {
+						rendered.Add(1)
+					} else {
+						skipped.Add(1)
+					}
+				}
+			}()
+		}
+		for x := nw.X; x <= se.X; x++ {
+			for y := nw.Y; y <= se.Y; y++ {
+				queue <- elevation.Tile{Z: z, X: x, Y: y}
+			}
+		}
+		close(queue)
+		wg.Wait()
+		if failed != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", failed)
+			os.Exit(1)
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "zoom %d: %d tiles rendered, %d skipped\n", z, rendered.Load(), skipped.Load())
+		}
+	}
+
+	if store == output {
+		return
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	if err := tiles.WritePMTiles(ctx, f, m, bbox, minZoom, maxZoom, metadata); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	m.Close()
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(store + suffix)
+	}
+}
+
+// render t into m unless it is already there
+//
+// when fromData is false the tile is built from its children. returns false if the tile
+// was skipped because it already exists or has no data
+func buildTile(ctx context.Context, m *tiles.MBTiles, t elevation.Tile, fromData bool, render func(context.Context, elevation.Tile) ([]byte, error), average func([]color.NRGBA) color.NRGBA) (bool, error) {
+	if ok, err := m.Has(ctx, t); err != nil || ok {
+		return false, err
+	}
+	var data []byte
+	var err error
+	if fromData {
+		data, err = render(ctx, t)
+	} else {
+		data, err = downsampleTile(ctx, m, t, average)
+	}
+	if errors.Is(err, service.ErrEmptyTile) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, m.Put(ctx, t, data)
+}
+
+// build t from the tiles at the next zoom level
+func downsampleTile(ctx context.Context, m *tiles.MBTiles, t elevation.Tile, average func([]color.NRGBA) color.NRGBA) ([]byte, error) {
+	var children [4]image.Image
+	found := false
+	for i, c := range t.Children() {
+		data, err := m.Get(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		if children[i], err = png.Decode(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("tile %s: %v", c, err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, service.ErrEmptyTile
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, elevation.DownsampleTile(children, average)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
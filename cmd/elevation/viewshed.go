@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func viewshed() {
+	viewshedCmd := flag.NewFlagSet("viewshed", flag.ExitOnError)
+	viewshedCmd.Usage = func() {
+		fmt.Printf("usage: %s viewshed [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("computes the area visible from an observer")
+		fmt.Println("")
+		fmt.Println("options:")
+		viewshedCmd.PrintDefaults()
+	}
+	var observerStr string
+	viewshedCmd.StringVar(&observerStr, "observer", "", "observer location: lat,lng (required)")
+	var output string
+	viewshedCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var format string
+	viewshedCmd.StringVar(&format, "f", "png", "output format (options: png, tiff, geojson, asc)")
+	opts := elevation.ViewshedOptions{Curvature: true, Refraction: elevation.StandardRefraction}
+	viewshedCmd.Float64Var(&opts.Radius, "r", 5000, "radius in meters")
+	viewshedCmd.Float64Var(&opts.ObserverHeight, "observer-height", 1.7, "observer height above the ground in meters")
+	viewshedCmd.Float64Var(&opts.TargetHeight, "target-height", 0, "target height above the ground in meters")
+	viewshedCmd.BoolVar(&opts.Curvature, "curvature", opts.Curvature, "account for the curvature of the earth")
+	viewshedCmd.Float64Var(&opts.Refraction, "refraction", opts.Refraction, "coefficient of refraction")
+
+	err := viewshedCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if viewshedCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	observer, err := elevation.ParseCoordinate(observerStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s, err := openService(viewshedCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	g, err := s.GetViewshed(context.TODO(), observer, 0, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	switch format {
+	case "png":
+		err = elevation.ViewshedToPNG(out, g)
+	case "tiff":
+		err = elevation.GridToGeoTIFF(out, g)
+	case "geojson":
+		err = elevation.WriteGeoJSON(out, elevation.ViewshedToGeoJSON(g))
+	case "asc":
+		err = elevation.GridToASCII(out, g)
+	default:
+		err = fmt.Errorf("invalid format: %s", format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
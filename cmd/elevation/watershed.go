@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"elevation"
+	"elevation/pkg/service"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func watershed() {
+	watershedCmd := flag.NewFlagSet("watershed", flag.ExitOnError)
+	watershedCmd.Usage = func() {
+		fmt.Printf("usage: %s watershed [options] [DB FILE]\n", os.Args[0])
+		fmt.Println("")
+		fmt.Println("writes the catchment upstream of a pour point as GeoJSON")
+		fmt.Println("")
+		fmt.Println("options:")
+		watershedCmd.PrintDefaults()
+	}
+	var pourStr string
+	watershedCmd.StringVar(&pourStr, "pour", "", "pour point: lat,lng (required)")
+	var bboxStr string
+	watershedCmd.StringVar(&bboxStr, "bbox", "", "bounding box to route flow in: minLat,minLng,maxLat,maxLng (default: 0.1 degrees around the pour point)")
+	var output string
+	watershedCmd.StringVar(&output, "o", "", "file name to output (default: stdout)")
+	var snap int
+	watershedCmd.IntVar(&snap, "snap", 5, "cells to search for the highest accumulation around the pour point")
+
+	err := watershedCmd.Parse(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if watershedCmd.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "must specifiy database file to use")
+		os.Exit(1)
+	}
+	pour, err := elevation.ParseCoordinate(pourStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	bbox := elevation.PathBoundingBox([]elevation.Coordinate{pour}, service.DefaultWatershedExtent)
+	if bboxStr != "" {
+		if bbox, err = elevation.ParseBoundingBox(bboxStr); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	s, err := openService(watershedCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	ws, err := s.GetWatershed(context.TODO(), pour, bbox, 0, snap)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if ws.Truncated {
+		fmt.Fprintln(os.Stderr, "warning: watershed reaches the edge of the bounding box and is likely incomplete")
+	}
+
+	out, err := createOutput(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+	if err := elevation.WriteGeoJSON(out, elevation.WatershedToGeoJSON(ws)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
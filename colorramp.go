@@ -0,0 +1,125 @@
+package elevation
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// an elevation and the color it is drawn with
+type ColorStop struct {
+	Elevation float64
+	Color     color.NRGBA
+}
+
+// colors interpolated between stops sorted by elevation
+type ColorRamp struct {
+	Stops []ColorStop
+	// color for voids
+	NoData color.NRGBA
+}
+
+// hypsometric tints from ocean blues through lowland greens to snow
+var Hypsometric = ColorRamp{Stops: []ColorStop{
+	{-10000, color.NRGBA{R: 20, G: 40, B: 120, A: 255}},
+	{-1, color.NRGBA{R: 120, G: 170, B: 220, A: 255}},
+	{0, color.NRGBA{R: 57, G: 151, B: 105, A: 255}},
+	{300, color.NRGBA{R: 117, G: 194, B: 93, A: 255}},
+	{800, color.NRGBA{R: 230, G: 230, B: 128, A: 255}},
+	{1500, color.NRGBA{R: 202, G: 158, B: 75, A: 255}},
+	{2500, color.NRGBA{R: 173, G: 127, B: 95, A: 255}},
+	{3500, color.NRGBA{R: 208, G: 190, B: 180, A: 255}},
+	{5000, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+}}
+
+// black at sea level to white at 4000 m
+var Grayscale = ColorRamp{Stops: []ColorStop{
+	{0, color.NRGBA{A: 255}},
+	{4000, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+}}
+
+// the built in color ramps by name
+var ColorRamps = map[string]ColorRamp{
+	"hypsometric": Hypsometric,
+	"grayscale":   Grayscale,
+}
+
+// color of elev, linearly interpolated between the surrounding stops
+//
+// elevations beyond the first or last stop get the color of that stop
+func (c ColorRamp) At(elev float64) color.NRGBA {
+	if math.IsNaN(elev) || len(c.Stops) == 0 {
+		return c.NoData
+	}
+	i := sort.Search(len(c.Stops), func(i int) bool { return c.Stops[i].Elevation >= elev })
+	if i == 0 {
+		return c.Stops[0].Color
+	}
+	if i == len(c.Stops) {
+		return c.Stops[len(c.Stops)-1].Color
+	}
+	a, b := c.Stops[i-1], c.Stops[i]
+	t := (elev - a.Elevation) / (b.Elevation - a.Elevation)
+	lerp := func(x uint8, y uint8) uint8 { return uint8(math.Round(float64(x) + t*(float64(y)-float64(x)))) }
+	return color.NRGBA{
+		R: lerp(a.Color.R, b.Color.R),
+		G: lerp(a.Color.G, b.Color.G),
+		B: lerp(a.Color.B, b.Color.B),
+		A: lerp(a.Color.A, b.Color.A),
+	}
+}
+
+// parse a GDAL color-relief text file (as used by gdaldem color-relief)
+//
+// each line is an elevation followed by R G B and an optional alpha, separated by spaces, tabs,
+// commas or colons. "nv" sets the color for voids. lines starting with # are comments.
+// percentages and named colors are not supported
+func ParseColorRelief(r io.Reader) (ColorRamp, error) {
+	ramp := ColorRamp{}
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ',' || r == ':'
+		})
+		if len(fields) != 4 && len(fields) != 5 {
+			return ColorRamp{}, fmt.Errorf("line %d: expected elevation R G B [A]", i)
+		}
+		c := color.NRGBA{A: 255}
+		for k, ch := range []*uint8{&c.R, &c.G, &c.B, &c.A}[:len(fields)-1] {
+			v, err := strconv.ParseUint(fields[k+1], 10, 8)
+			if err != nil {
+				return ColorRamp{}, fmt.Errorf("line %d: invalid color component: %s", i, fields[k+1])
+			}
+			*ch = uint8(v)
+		}
+		if strings.EqualFold(fields[0], "nv") {
+			ramp.NoData = c
+			continue
+		}
+		if strings.HasSuffix(fields[0], "%") {
+			return ColorRamp{}, fmt.Errorf("line %d: percentages are not supported", i)
+		}
+		elev, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return ColorRamp{}, fmt.Errorf("line %d: invalid elevation: %s", i, fields[0])
+		}
+		ramp.Stops = append(ramp.Stops, ColorStop{Elevation: elev, Color: c})
+	}
+	if err := scanner.Err(); err != nil {
+		return ColorRamp{}, err
+	}
+	if len(ramp.Stops) == 0 {
+		return ColorRamp{}, fmt.Errorf("no color stops found")
+	}
+	sort.SliceStable(ramp.Stops, func(a int, b int) bool { return ramp.Stops[a].Elevation < ramp.Stops[b].Elevation })
+	return ramp, nil
+}
@@ -0,0 +1,207 @@
+package elevation
+
+import "math"
+
+// a single isoline
+type Contour struct {
+	Elevation float64
+	// true if the elevation is a multiple of the index interval
+	Index bool
+	// true if the line forms a ring
+	Closed    bool
+	Positions []Position
+}
+
+// identifies a point where a contour crosses the grid
+//
+// a horizontal edge joins (row, col) and (row, col+1).
+// a vertical edge joins (row, col) and (row+1, col)
+type edgeKey struct {
+	row      int
+	col      int
+	vertical bool
+}
+
+type segment [2]edgeKey
+
+// generate contour lines every interval meters using marching squares
+//
+// lines stop at voids. index contours are flagged every indexInterval meters (0 to disable)
+func (g *Grid) Contours(interval float64, indexInterval float64) []Contour {
+	contours := []Contour{}
+	if interval <= 0 {
+		return contours
+	}
+	lo, hi := g.MinMax()
+	if math.IsNaN(lo) {
+		return contours
+	}
+	// levels are computed from k rather than summed so they do not drift
+	for k := math.Ceil(lo / interval); k*interval <= hi; k++ {
+		level := k * interval
+		index := indexInterval > 0 && isMultiple(level, indexInterval)
+		for _, line := range g.contourLevel(level) {
+			line.Index = index
+			contours = append(contours, line)
+		}
+	}
+	return contours
+}
+
+// true if v is a multiple of m, allowing for float error
+func isMultiple(v float64, m float64) bool {
+	r := v / m
+	return math.Abs(r-math.Round(r)) < 1e-9*math.Max(1, math.Abs(r))
+}
+
+// trace all the lines for a single level
+func (g *Grid) contourLevel(level float64) []Contour {
+	segments := []segment{}
+	for row := 0; row < g.Rows-1; row++ {
+		for col := 0; col < g.Cols-1; col++ {
+			segments = append(segments, g.cellSegments(row, col, level)...)
+		}
+	}
+	return g.joinSegments(segments, level)
+}
+
+// marching squares segments for the cell with row,col as its top left corner
+func (g *Grid) cellSegments(row int, col int, level float64) []segment {
+	tl, tr := g.At(row, col), g.At(row, col+1)
+	bl, br := g.At(row+1, col), g.At(row+1, col+1)
+	if math.IsNaN(tl) || math.IsNaN(tr) || math.IsNaN(bl) || math.IsNaN(br) {
+		return nil
+	}
+	above := func(v float64) int {
+		if v >= level {
+			return 1
+		}
+		return 0
+	}
+	c := above(tl)<<3 | above(tr)<<2 | above(br)<<1 | above(bl)
+
+	top := edgeKey{row, col, false}
+	bottom := edgeKey{row + 1, col, false}
+	left := edgeKey{row, col, true}
+	right := edgeKey{row, col + 1, true}
+
+	switch c {
+	case 1, 14:
+		return []segment{{left, bottom}}
+	case 2, 13:
+		return []segment{{bottom, right}}
+	case 3, 12:
+		return []segment{{left, right}}
+	case 4, 11:
+		return []segment{{top, right}}
+	case 6, 9:
+		return []segment{{top, bottom}}
+	case 7, 8:
+		return []segment{{left, top}}
+	case 5:
+		// saddle, resolved with the average of the corners
+		if (tl+tr+bl+br)/4 >= level {
+			return []segment{{left, top}, {bottom, right}}
+		}
+		return []segment{{left, bottom}, {top, right}}
+	case 10:
+		if (tl+tr+bl+br)/4 >= level {
+			return []segment{{top, right}, {left, bottom}}
+		}
+		return []segment{{left, top}, {bottom, right}}
+	default:
+		return nil
+	}
+}
+
+// chain segments that share an edge into lines
+func (g *Grid) joinSegments(segments []segment, level float64) []Contour {
+	byEdge := map[edgeKey][]int{}
+	for i, s := range segments {
+		byEdge[s[0]] = append(byEdge[s[0]], i)
+		byEdge[s[1]] = append(byEdge[s[1]], i)
+	}
+	used := make([]bool, len(segments))
+
+	// follow unused segments away from edge
+	follow := func(edge edgeKey) []edgeKey {
+		path := []edgeKey{}
+		for {
+			next := -1
+			for _, i := range byEdge[edge] {
+				if !used[i] {
+					next = i
+					break
+				}
+			}
+			if next == -1 {
+				return path
+			}
+			used[next] = true
+			if segments[next][0] == edge {
+				edge = segments[next][1]
+			} else {
+				edge = segments[next][0]
+			}
+			path = append(path, edge)
+		}
+	}
+
+	lines := []Contour{}
+	for i, s := range segments {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		forward := follow(s[1])
+		backward := follow(s[0])
+
+		edges := make([]edgeKey, 0, len(forward)+len(backward)+2)
+		for j := len(backward) - 1; j >= 0; j-- {
+			edges = append(edges, backward[j])
+		}
+		edges = append(edges, s[0], s[1])
+		edges = append(edges, forward...)
+
+		positions := make([]Position, len(edges))
+		for j, e := range edges {
+			positions[j] = g.edgePosition(e, level)
+		}
+		closed := len(edges) > 2 && edges[0] == edges[len(edges)-1]
+		lines = append(lines, Contour{Elevation: level, Closed: closed, Positions: positions})
+	}
+	return lines
+}
+
+// position where level crosses the edge
+func (g *Grid) edgePosition(e edgeKey, level float64) Position {
+	a := g.At(e.row, e.col)
+	var b float64
+	if e.vertical {
+		b = g.At(e.row+1, e.col)
+	} else {
+		b = g.At(e.row, e.col+1)
+	}
+	t := 0.5
+	if a != b {
+		t = (level - a) / (b - a)
+	}
+	s := float64(g.Spacing)
+	if e.vertical {
+		return Position{g.Longitude(e.col), g.Latitude(e.row) - t*s}
+	}
+	return Position{g.Longitude(e.col) + t*s, g.Latitude(e.row)}
+}
+
+// convert contours to a GeoJSON feature collection of LineStrings
+func ContoursToGeoJSON(contours []Contour) FeatureCollection {
+	features := make([]Feature, len(contours))
+	for i, c := range contours {
+		features[i] = NewFeature(NewLineString(c.Positions), map[string]any{
+			"elevation": c.Elevation,
+			"index":     c.Index,
+			"closed":    c.Closed,
+		})
+	}
+	return NewFeatureCollection(features)
+}
@@ -0,0 +1,31 @@
+package elevation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestContoursIndex(t *testing.T) {
+	// ramps from 0 to 3.6 from west to east
+	g := newGrid(1, 0, SRTM1, 3, 37)
+	for row := range g.Rows {
+		for col := range g.Cols {
+			g.Set(row, col, float64(col)*0.1)
+		}
+	}
+	index := []float64{}
+	for _, c := range g.Contours(0.1, 1) {
+		if c.Index {
+			index = append(index, c.Elevation)
+		}
+	}
+	want := []float64{1, 2, 3}
+	if len(index) != len(want) {
+		t.Fatalf("got index contours at %v, want %v", index, want)
+	}
+	for i := range want {
+		if math.Abs(index[i]-want[i]) > 1e-9 {
+			t.Errorf("got index contours at %v, want %v", index, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package elevation
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// a file format a dem can be read from
+type DEMFormat string
+
+const (
+	// SRTM .hgt, georeferenced by the tile name
+	HGTFormat DEMFormat = "hgt"
+	// ESRI ASCII grid (.asc)
+	ASCIIGridFormat DEMFormat = "asc"
+	// ESRI BIL with a .hdr header
+	BILFormat DEMFormat = "bil"
+	// single band GeoTIFF in EPSG:4326 (Copernicus DEM, ASTER GDEM)
+	GeoTIFFFormat DEMFormat = "tiff"
+)
+
+// detect the format from the extension of path
+func DEMFormatFromPath(path string) (DEMFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hgt", ".zip":
+		// hgt files are downloaded zipped
+		return HGTFormat, nil
+	case ".asc":
+		return ASCIIGridFormat, nil
+	case ".bil":
+		return BILFormat, nil
+	case ".tif", ".tiff":
+		return GeoTIFFFormat, nil
+	default:
+		return "", fmt.Errorf("unknown dem format: %s", path)
+	}
+}
+
+func ParseDEMFormat(s string) (DEMFormat, error) {
+	switch f := DEMFormat(strings.ToLower(s)); f {
+	case HGTFormat, ASCIIGridFormat, BILFormat, GeoTIFFFormat:
+		return f, nil
+	case "tif":
+		return GeoTIFFFormat, nil
+	default:
+		return "", fmt.Errorf("invalid dem format: %s", s)
+	}
+}
+
+// read an hgt file for the tile with its south west corner at lat,lng
+//
+// r may also be a zip holding the hgt file, as downloaded from the usgs
+func ReadHGT(r io.Reader, lat int, lng int) (*Grid, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		if data, err = unzipHGT(data); err != nil {
+			return nil, err
+		}
+	}
+	spacing, err := HGTSpacing(len(data))
+	if err != nil {
+		return nil, err
+	}
+	records, err := ProcessHGT(bytes.NewReader(data), lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	bbox := BoundingBox{
+		MinLatitude:  float64(lat),
+		MinLongitude: float64(lng),
+		MaxLatitude:  float64(lat + 1),
+		MaxLongitude: float64(lng + 1),
+	}
+	return NewGridFromRecords(bbox, spacing, records), nil
+}
+
+// content of the first .hgt file in a zip
+func unzipHGT(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".hgt") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("no hgt file in zip")
+}
+
+// the 1° tiles covered by the grid, all from product
+func GridTiles(g *Grid, product Product) []DEMTile {
+	bbox := g.BoundingBox()
+	// cells on the edge of a tile belong to both tiles, only count tiles the grid reaches into
+	const eps = 1e-9
+	south, west := int(math.Floor(bbox.MinLatitude+eps)), int(math.Floor(bbox.MinLongitude+eps))
+	north := max(int(math.Ceil(bbox.MaxLatitude-eps)), south+1)
+	east := max(int(math.Ceil(bbox.MaxLongitude-eps)), west+1)
+	tiles := make([]DEMTile, 0, (north-south)*(east-west))
+	for lat := south; lat < north; lat++ {
+		for lng := west; lng < east; lng++ {
+			tiles = append(tiles, DEMTile{Latitude: lat, Longitude: lng, Product: product, Spacing: g.Spacing})
+		}
+	}
+	return tiles
+}
+
+// a record for every cell that is not a void, row by row from the north west
+//
+// for a grid read by ReadHGT these are the records ProcessHGT returns
+func GridRecords(g *Grid) []HGTRecord {
+	records := make([]HGTRecord, 0, len(g.Data))
+	for row := range g.Rows {
+		lat := g.Latitude(row)
+		for col := range g.Cols {
+			v := g.At(row, col)
+			if math.IsNaN(v) {
+				continue
+			}
+			records = append(records, HGTRecord{Latitude: lat, Longitude: g.Longitude(col), Elevation: v})
+		}
+	}
+	return records
+}
+
+// number of cells per degree if the spacing is a whole fraction of a degree, otherwise 0
+func cellsPerDegree(spacing float64) float64 {
+	n := math.Round(1 / spacing)
+	if n > 0 && math.Abs(1/spacing-n) < 1e-6*n {
+		return n
+	}
+	return 0
+}
+
+// build a grid from raster values, row by row from the north west cell
+//
+// north and west are the center of the first cell and dx, dy the cell size in degrees. spacings
+// and origins that are a float error off whole fractions of a degree are snapped, so the records
+// line up with those of hgt files. when dx differs from dy (e.g. Copernicus DEM north of 50°)
+// the rows are linearly resampled to dy
+func newGridFromRaster(north float64, west float64, dx float64, dy float64, width int, height int, values []float64) (*Grid, error) {
+	if width < 1 || height < 1 || dx <= 0 || dy <= 0 {
+		return nil, fmt.Errorf("invalid raster: %dx%d cells of %gx%g degrees", width, height, dx, dy)
+	}
+	if len(values) != width*height {
+		return nil, fmt.Errorf("invalid raster: expected %d values, got %d", width*height, len(values))
+	}
+	if n := cellsPerDegree(dx); n > 0 {
+		dx = 1 / n
+	}
+	spacing := dy
+	if n := cellsPerDegree(dy); n > 0 {
+		spacing = 1 / n
+		if math.Abs(north*n-math.Round(north*n)) < 1e-3 {
+			north = math.Round(north*n) / n
+		}
+		if math.Abs(west*n-math.Round(west*n)) < 1e-3 {
+			west = math.Round(west*n) / n
+		}
+	}
+
+	if dx == spacing {
+		g := newGrid(north, west, Spacing(spacing), height, width)
+		copy(g.Data, values)
+		return g, nil
+	}
+	cols := int(math.Floor(float64(width-1)*dx/spacing+1e-9)) + 1
+	g := newGrid(north, west, Spacing(spacing), height, cols)
+	for row := range height {
+		for col := range cols {
+			x := float64(col) * spacing / dx
+			x0 := int(math.Floor(x))
+			x1 := min(x0+1, width-1)
+			f := x - float64(x0)
+			a, b := values[row*width+x0], values[row*width+x1]
+			switch {
+			case f < 1e-9:
+				g.Set(row, col, a)
+			case 1-f < 1e-9:
+				g.Set(row, col, b)
+			default:
+				// NaN if either side is a void
+				g.Set(row, col, a*(1-f)+b*f)
+			}
+		}
+	}
+	return g, nil
+}
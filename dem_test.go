@@ -0,0 +1,375 @@
+package elevation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// south west corner of the test tile
+const testLat, testLng = 46, 8
+
+// hgt value of a void
+const testVoid = -32768
+
+// a 3 arc-second hgt file with a different elevation at every cell, row by row from the north
+//
+// there is a block of voids and the last cells of the south edge are voids
+func testHGT() ([]byte, []int16) {
+	const n = 1201
+	values := make([]int16, n*n)
+	data := make([]byte, 2*n*n)
+	for row := range n {
+		for col := range n {
+			v := int16((row*7+col*13)%4000 - 200)
+			if (row >= 100 && row < 150 && col >= 200 && col < 300) || (row == n-1 && col >= n-10) {
+				v = testVoid
+			}
+			values[row*n+col] = v
+			binary.BigEndian.PutUint16(data[2*(row*n+col):], uint16(v))
+		}
+	}
+	return data, values
+}
+
+// the records ProcessHGT reads from the test tile
+func testRecords(t *testing.T, hgt []byte) []HGTRecord {
+	t.Helper()
+	records, err := ProcessHGT(bytes.NewReader(hgt), testLat, testLng)
+	if err != nil {
+		t.Fatalf("ProcessHGT: %v", err)
+	}
+	// voids are skipped
+	if want := 1201*1201 - 50*100 - 10; len(records) != want {
+		t.Fatalf("ProcessHGT returned %d records, want %d", len(records), want)
+	}
+	return records
+}
+
+// fail unless the grid holds exactly the records
+func checkRecords(t *testing.T, g *Grid, want []HGTRecord) {
+	t.Helper()
+	if g.Spacing != SRTM3 {
+		t.Errorf("spacing = %v, want %v", g.Spacing, SRTM3)
+	}
+	got := GridRecords(g)
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i].Latitude-want[i].Latitude) > 1e-9 ||
+			math.Abs(got[i].Longitude-want[i].Longitude) > 1e-9 ||
+			got[i].Elevation != want[i].Elevation {
+			t.Fatalf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGeoTIFFRoundTrip(t *testing.T) {
+	hgt, _ := testHGT()
+	g, err := ReadHGT(bytes.NewReader(hgt), testLat, testLng)
+	if err != nil {
+		t.Fatalf("ReadHGT: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := GridToGeoTIFF(&buf, g); err != nil {
+		t.Fatalf("GridToGeoTIFF: %v", err)
+	}
+	got, err := ReadGeoTIFF(&buf)
+	if err != nil {
+		t.Fatalf("ReadGeoTIFF: %v", err)
+	}
+	checkRecords(t, got, testRecords(t, hgt))
+}
+
+// encode tiff flavoured lzw as libtiff does, starting with a clear code
+func tiffLZWEncode(data []byte) []byte {
+	const (
+		clearCode = 256
+		eoiCode   = 257
+	)
+	var out []byte
+	var buf uint32
+	bits, width := 0, 9
+	write := func(code int) {
+		buf = buf<<width | uint32(code)
+		bits += width
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(buf>>bits))
+		}
+	}
+	table := map[string]int{}
+	next := 258
+	write(clearCode)
+	if len(data) == 0 {
+		write(eoiCode)
+	} else {
+		code := func(s string) int {
+			if len(s) == 1 {
+				return int(s[0])
+			}
+			return table[s]
+		}
+		prefix := string(data[:1])
+		for _, c := range data[1:] {
+			s := prefix + string([]byte{c})
+			if _, ok := table[s]; ok {
+				prefix = s
+				continue
+			}
+			write(code(prefix))
+			table[s] = next
+			next++
+			if next >= 1<<width && width < 12 {
+				width++
+			}
+			if next >= 4094 {
+				write(clearCode)
+				clear(table)
+				next, width = 258, 9
+			}
+			prefix = string([]byte{c})
+		}
+		write(code(prefix))
+		// the decoder adds an entry for the last code too, which can widen the end code
+		if next++; next >= 1<<width && width < 12 {
+			width++
+		}
+		write(eoiCode)
+	}
+	if bits > 0 {
+		out = append(out, byte(buf<<(8-bits)))
+	}
+	return out
+}
+
+// apply the tiff predictor to a row of little endian samples in place
+func tiffPredict(row []byte, predictor int, size int) {
+	switch predictor {
+	case 2:
+		for x := len(row) - size; x >= size; x -= size {
+			switch size {
+			case 2:
+				binary.LittleEndian.PutUint16(row[x:], binary.LittleEndian.Uint16(row[x:])-binary.LittleEndian.Uint16(row[x-size:]))
+			case 4:
+				binary.LittleEndian.PutUint32(row[x:], binary.LittleEndian.Uint32(row[x:])-binary.LittleEndian.Uint32(row[x-size:]))
+			}
+		}
+	case 3:
+		count := len(row) / size
+		tmp := make([]byte, len(row))
+		for i := range count {
+			for b := range size {
+				tmp[b*count+i] = row[i*size+size-1-b]
+			}
+		}
+		copy(row, tmp)
+		for x := len(row) - 1; x > 0; x-- {
+			row[x] -= row[x-1]
+		}
+	}
+}
+
+// a little endian geotiff of the test tile in strips of rowsPerStrip rows
+func testGeoTIFF(t *testing.T, values []int16, format int, compression int, predictor int, rowsPerStrip int) []byte {
+	t.Helper()
+	const n = 1201
+	size := 2
+	if format == 3 {
+		size = 4
+	}
+	var strips [][]byte
+	for start := 0; start < n; start += rowsPerStrip {
+		var block []byte
+		for row := start; row < min(start+rowsPerStrip, n); row++ {
+			line := make([]byte, n*size)
+			for col := range n {
+				v := values[row*n+col]
+				if format == 3 {
+					binary.LittleEndian.PutUint32(line[col*size:], math.Float32bits(float32(v)))
+				} else {
+					binary.LittleEndian.PutUint16(line[col*size:], uint16(v))
+				}
+			}
+			tiffPredict(line, predictor, size)
+			block = append(block, line...)
+		}
+		switch compression {
+		case tiffLZW:
+			block = tiffLZWEncode(block)
+		case tiffDeflate:
+			var buf bytes.Buffer
+			zw := zlib.NewWriter(&buf)
+			zw.Write(block)
+			zw.Close()
+			block = buf.Bytes()
+		}
+		strips = append(strips, block)
+	}
+
+	// strips follow the header, the ifd and its values follow the strips
+	offsets := make([]uint32, len(strips))
+	counts := make([]uint32, len(strips))
+	data := []byte{'I', 'I', 42, 0, 0, 0, 0, 0}
+	for i, s := range strips {
+		offsets[i], counts[i] = uint32(len(data)), uint32(len(s))
+		data = append(data, s...)
+		if len(data)%2 == 1 {
+			data = append(data, 0)
+		}
+	}
+	s := float64(SRTM3)
+	entries := []tiffEntry{
+		tiffLongs(256, n),
+		tiffLongs(257, n),
+		tiffShorts(258, uint16(8*size)),
+		tiffShorts(259, uint16(compression)),
+		tiffShorts(262, 1),
+		tiffLongs(273, offsets...),
+		tiffShorts(277, 1),
+		tiffLongs(278, uint32(rowsPerStrip)),
+		tiffLongs(279, counts...),
+		tiffShorts(284, 1),
+		tiffShorts(317, uint16(predictor)),
+		tiffShorts(339, uint16(format)),
+		tiffDoubles(33550, s, s, 0),
+		tiffDoubles(33922, 0, 0, 0, testLng-s/2, testLat+1+s/2, 0),
+		tiffShorts(34735,
+			1, 1, 0, 3,
+			gtModelTypeGeoKey, 0, 1, modelTypeGeographic,
+			gtRasterTypeGeoKey, 0, 1, rasterPixelIsArea,
+			geographicTypeGeoKey, 0, 1, epsgWGS84,
+		),
+		tiffString(42113, strconv.Itoa(testVoid)),
+	}
+	sort.Slice(entries, func(i int, j int) bool { return entries[i].tag < entries[j].tag })
+	ifd := uint32(len(data))
+	binary.LittleEndian.PutUint32(data[4:], ifd)
+	extra := ifd + uint32(2+12*len(entries)+4)
+	var tail []byte
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(entries)))
+	for _, e := range entries {
+		data = binary.LittleEndian.AppendUint16(data, e.tag)
+		data = binary.LittleEndian.AppendUint16(data, e.typ)
+		data = binary.LittleEndian.AppendUint32(data, e.count)
+		if len(e.data) <= 4 {
+			data = append(data, e.data...)
+			data = append(data, make([]byte, 4-len(e.data))...)
+		} else {
+			data = binary.LittleEndian.AppendUint32(data, extra+uint32(len(tail)))
+			tail = append(tail, e.data...)
+		}
+	}
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	return append(data, tail...)
+}
+
+func TestReadGeoTIFFStrips(t *testing.T) {
+	hgt, values := testHGT()
+	want := testRecords(t, hgt)
+	tests := []struct {
+		name        string
+		format      int
+		compression int
+		predictor   int
+	}{
+		{"uncompressed int16", 2, tiffUncompressed, 1},
+		{"lzw int16", 2, tiffLZW, 1},
+		{"lzw int16 horizontal predictor", 2, tiffLZW, 2},
+		{"lzw float32 floating point predictor", 3, tiffLZW, 3},
+		{"deflate float32 floating point predictor", 3, tiffDeflate, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1201 rows leave a short last strip
+			data := testGeoTIFF(t, values, tt.format, tt.compression, tt.predictor, 16)
+			g, err := ReadGeoTIFF(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadGeoTIFF: %v", err)
+			}
+			checkRecords(t, g, want)
+		})
+	}
+}
+
+func TestTIFFLZWRoundTrip(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("abababababababab"),
+		bytes.Repeat([]byte{0}, 100000),
+		[]byte(strings.Repeat("the quick brown fox jumps over the lazy dog ", 2000)),
+		bytes.Repeat([]byte{0x38, 0xff, 0x80, 0x7f}, 10000),
+	} {
+		got, err := tiffLZWDecode(tiffLZWEncode(data), len(data))
+		if err != nil {
+			t.Fatalf("tiffLZWDecode(%d bytes): %v", len(data), err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("tiffLZWDecode(%d bytes) returned %d different bytes", len(data), len(got))
+		}
+	}
+}
+
+func TestReadBIL(t *testing.T) {
+	hgt, _ := testHGT()
+	// an hgt file is a big endian bil
+	hdr := fmt.Sprintf(`BYTEORDER M
+LAYOUT BIL
+NROWS 1201
+NCOLS 1201
+NBANDS 1
+NBITS 16
+PIXELTYPE SIGNEDINT
+ULXMAP %d
+ULYMAP %d
+XDIM %s
+YDIM %s
+NODATA -32768
+`, testLng, testLat+1, strconv.FormatFloat(float64(SRTM3), 'g', 15, 64), strconv.FormatFloat(float64(SRTM3), 'g', 15, 64))
+	g, err := ReadBIL(bytes.NewReader(hgt), strings.NewReader(hdr))
+	if err != nil {
+		t.Fatalf("ReadBIL: %v", err)
+	}
+	checkRecords(t, g, testRecords(t, hgt))
+}
+
+func TestReadASCIIGrid(t *testing.T) {
+	hgt, values := testHGT()
+	want := testRecords(t, hgt)
+	s := float64(SRTM3)
+	var body strings.Builder
+	for row := range 1201 {
+		for col := range 1201 {
+			if col > 0 {
+				body.WriteByte(' ')
+			}
+			body.WriteString(strconv.Itoa(int(values[row*1201+col])))
+		}
+		body.WriteByte('\n')
+	}
+	tests := []struct {
+		name   string
+		origin string
+	}{
+		{"corner", fmt.Sprintf("xllcorner %.15f\nyllcorner %.15f\n", testLng-s/2, testLat-s/2)},
+		{"center", fmt.Sprintf("xllcenter %d\nyllcenter %d\n", testLng, testLat)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asc := fmt.Sprintf("ncols 1201\nnrows 1201\n%scellsize %.15f\nNODATA_value -32768\n", tt.origin, s) + body.String()
+			g, err := ReadASCIIGrid(strings.NewReader(asc))
+			if err != nil {
+				t.Fatalf("ReadASCIIGrid: %v", err)
+			}
+			checkRecords(t, g, want)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package elevation
+
+import (
+	"fmt"
+	"math"
+)
+
+// a reference surface for earthwork volumes
+//
+// a horizontal plane has zero slopes
+type Plane struct {
+	Origin Coordinate `json:"origin"`
+	// elevation (m) at the origin
+	Elevation float64 `json:"elevation"`
+	// rise (m) per meter to the east and north
+	SlopeEast  float64 `json:"slope_east"`
+	SlopeNorth float64 `json:"slope_north"`
+}
+
+// offset in meters of lat,lng from the plane origin
+func (p Plane) offset(lat float64, lng float64) (float64, float64) {
+	x := toRadians(lng-p.Origin.Longitude) * EarthRadius * math.Cos(toRadians(p.Origin.Latitude))
+	y := toRadians(lat-p.Origin.Latitude) * EarthRadius
+	return x, y
+}
+
+// elevation of the plane at lat,lng
+func (p Plane) At(lat float64, lng float64) float64 {
+	x, y := p.offset(lat, lng)
+	return p.Elevation + p.SlopeEast*x + p.SlopeNorth*y
+}
+
+// fit a plane to the cells where inside is true using area weighted least squares
+func (g *Grid) FitPlane(inside []bool) (Plane, error) {
+	var w, lat, lng float64
+	g.eachInside(inside, func(row int, col int, area float64) {
+		w += area
+		lat += area * g.Latitude(row)
+		lng += area * g.Longitude(col)
+	})
+	if w == 0 {
+		return Plane{}, fmt.Errorf("no data inside of the polygon")
+	}
+	p := Plane{Origin: Coordinate{Latitude: lat / w, Longitude: lng / w}}
+
+	var z, sxx, sxy, syy, sxz, syz float64
+	g.eachInside(inside, func(row int, col int, area float64) {
+		z += area * g.At(row, col)
+	})
+	p.Elevation = z / w
+	g.eachInside(inside, func(row int, col int, area float64) {
+		x, y := p.offset(g.Latitude(row), g.Longitude(col))
+		dz := g.At(row, col) - p.Elevation
+		sxx += area * x * x
+		sxy += area * x * y
+		syy += area * y * y
+		sxz += area * x * dz
+		syz += area * y * dz
+	})
+	// a single row or column of cells doesn't constrain both slopes
+	if det := sxx*syy - sxy*sxy; math.Abs(det) > 1e-9*sxx*syy {
+		p.SlopeEast = (sxz*syy - syz*sxy) / det
+		p.SlopeNorth = (syz*sxx - sxz*sxy) / det
+	}
+	return p, nil
+}
+
+// visit every non void cell where inside is true along with its area in square meters
+func (g *Grid) eachInside(inside []bool, visit func(row int, col int, area float64)) {
+	for row := range g.Rows {
+		dx, dy := g.CellSize(row)
+		for col := range g.Cols {
+			if inside[row*g.Cols+col] && g.Valid(row, col) {
+				visit(row, col, dx*dy)
+			}
+		}
+	}
+}
+
+// earthwork volumes needed to bring the terrain to a plane
+type CutFill struct {
+	Plane Plane `json:"plane"`
+	// number of cells inside of the polygon with data
+	Cells int `json:"cells"`
+	// number of void cells inside of the polygon, which are left out of the volumes
+	Voids int `json:"voids"`
+	// area in square meters
+	Area float64 `json:"area"`
+	// volume (m³) of terrain above the plane
+	Cut float64 `json:"cut"`
+	// volume (m³) needed to raise the terrain below the plane
+	Fill float64 `json:"fill"`
+	// cut minus fill
+	Net float64 `json:"net"`
+}
+
+// compute the cut and fill volumes between the terrain and plane for the cells where inside is true
+//
+// each cell is weighted by its area, which shrinks with latitude
+func (g *Grid) CutFill(inside []bool, plane Plane) (CutFill, error) {
+	cf := CutFill{Plane: plane}
+	for i, in := range inside {
+		if in && math.IsNaN(g.Data[i]) {
+			cf.Voids++
+		}
+	}
+	g.eachInside(inside, func(row int, col int, area float64) {
+		dz := g.At(row, col) - plane.At(g.Latitude(row), g.Longitude(col))
+		cf.Cells++
+		cf.Area += area
+		if dz > 0 {
+			cf.Cut += dz * area
+		} else {
+			cf.Fill -= dz * area
+		}
+	})
+	if cf.Cells == 0 {
+		return CutFill{}, fmt.Errorf("no data inside of the polygon")
+	}
+	cf.Net = cf.Cut - cf.Fill
+	return cf, nil
+}
@@ -0,0 +1,131 @@
+package elevation
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// values of a flood mask
+const (
+	Dry     = 0.0
+	Flooded = 1.0
+)
+
+// the area inundated by a water level
+type Flood struct {
+	// water surface elevation in meters
+	Level float64 `json:"level"`
+	// number of flooded cells
+	Cells int `json:"cells"`
+	// flooded area in square meters
+	Area float64 `json:"area"`
+	// water volume in cubic meters
+	Volume   float64 `json:"volume"`
+	MaxDepth float64 `json:"max_depth"`
+	// volume divided by area
+	MeanDepth float64 `json:"mean_depth"`
+	// true if the flood reaches the edge of the grid, in which case it likely continues outside of it
+	Truncated bool `json:"truncated"`
+	// Flooded or Dry for every cell, NaN for voids
+	Mask *Grid `json:"-"`
+}
+
+// find the cells at or below level
+//
+// when seed is set only the cells connected to it (including diagonally) are flooded,
+// otherwise every cell at or below level is
+func (g *Grid) Flood(level float64, seed *Coordinate) (Flood, error) {
+	f := Flood{Level: level, Mask: g.Like()}
+	for i, v := range g.Data {
+		if !math.IsNaN(v) {
+			f.Mask.Data[i] = Dry
+		}
+	}
+	below := func(row int, col int) bool {
+		return g.Valid(row, col) && g.At(row, col) <= level
+	}
+	if seed == nil {
+		for row := range g.Rows {
+			for col := range g.Cols {
+				if below(row, col) {
+					f.flood(g, row, col)
+				}
+			}
+		}
+		return f, nil
+	}
+
+	row, col := g.Cell(seed.Latitude, seed.Longitude)
+	if !g.Valid(row, col) {
+		return Flood{}, fmt.Errorf("no data at seed %f, %f", seed.Latitude, seed.Longitude)
+	}
+	if !below(row, col) {
+		return Flood{}, fmt.Errorf("seed elevation %.1f is above the water level", g.At(row, col))
+	}
+	queue := [][2]int{{row, col}}
+	f.flood(g, row, col)
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range d8 {
+			r, cc := c[0]+d.dr, c[1]+d.dc
+			if !below(r, cc) || f.Mask.At(r, cc) == Flooded {
+				continue
+			}
+			f.flood(g, r, cc)
+			queue = append(queue, [2]int{r, cc})
+		}
+	}
+	return f, nil
+}
+
+// mark row,col as flooded and add it to the totals
+func (f *Flood) flood(g *Grid, row int, col int) {
+	f.Mask.Set(row, col, Flooded)
+	dx, dy := g.CellSize(row)
+	depth := f.Level - g.At(row, col)
+	f.Cells++
+	f.Area += dx * dy
+	f.Volume += depth * dx * dy
+	f.MaxDepth = math.Max(f.MaxDepth, depth)
+	if f.Area > 0 {
+		f.MeanDepth = f.Volume / f.Area
+	}
+	if row == 0 || col == 0 || row == g.Rows-1 || col == g.Cols-1 {
+		f.Truncated = true
+	}
+}
+
+// convert the flood to a GeoJSON feature collection with a single MultiPolygon
+func FloodToGeoJSON(f Flood) FeatureCollection {
+	polygons := f.Mask.Polygonize(func(v float64) bool { return v == Flooded })
+	feature := NewFeature(NewMultiPolygon(polygons), map[string]any{
+		"level":      f.Level,
+		"cells":      f.Cells,
+		"area":       f.Area,
+		"volume":     f.Volume,
+		"max_depth":  f.MaxDepth,
+		"mean_depth": f.MeanDepth,
+		"truncated":  f.Truncated,
+	})
+	return NewFeatureCollection([]Feature{feature})
+}
+
+// write the flood mask to w as a png
+//
+// flooded cells are translucent blue and everything else is transparent so it can be used as an overlay
+func FloodToPNG(w io.Writer, f Flood) error {
+	img := image.NewNRGBA(image.Rect(0, 0, f.Mask.Cols, f.Mask.Rows))
+	for row := range f.Mask.Rows {
+		for col := range f.Mask.Cols {
+			if f.Mask.At(row, col) == Flooded {
+				img.SetNRGBA(col, row, color.NRGBA{R: 0x1f, G: 0x6f, B: 0xd1, A: 0xb0})
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
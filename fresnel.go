@@ -0,0 +1,187 @@
+package elevation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// speed of light in m/s
+const SpeedOfLight = 299792458.0
+
+// the standard atmosphere k-factor used for radio planning
+const StandardKFactor = 4.0 / 3.0
+
+// fraction of the first Fresnel zone that should be clear of obstructions
+const MinFresnelClearance = 0.6
+
+// parameters for a point to point radio link
+type FresnelOptions struct {
+	// antenna heights (m) above the ground
+	FromHeight float64
+	ToHeight   float64
+	// link frequency in Hz
+	Frequency float64
+	// ratio of the effective earth radius to the true earth radius
+	KFactor float64
+}
+
+// a sampled point between the two antennas
+//
+// heights are in meters above sea level, clearance is the height of the sight line above the terrain
+type FresnelPoint struct {
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	Distance      float64 `json:"distance"`
+	Elevation     float64 `json:"elevation"`
+	EarthBulge    float64 `json:"earth_bulge"`
+	LineOfSight   float64 `json:"line_of_sight"`
+	FresnelRadius float64 `json:"fresnel_radius"`
+	Clearance     float64 `json:"clearance"`
+	// clearance as a percentage of the first Fresnel zone radius
+	ClearancePercent float64 `json:"clearance_percent"`
+}
+
+type FresnelAnalysis struct {
+	Distance   float64 `json:"distance"`
+	Frequency  float64 `json:"frequency"`
+	Wavelength float64 `json:"wavelength"`
+	KFactor    float64 `json:"k_factor"`
+	// true if the terrain never crosses the sight line
+	LineOfSight bool `json:"line_of_sight"`
+	// true if at least 60% of the first Fresnel zone is clear everywhere
+	FresnelClear bool `json:"fresnel_clear"`
+	// the point with the lowest clearance percentage. nil when no point between the
+	// antennas has terrain to clear
+	Worst  *FresnelPoint  `json:"worst"`
+	Points []FresnelPoint `json:"points"`
+}
+
+// radius (m) of the first Fresnel zone at d1 meters from one end and d2 meters from the other
+func FresnelRadius(wavelength float64, d1 float64, d2 float64) float64 {
+	if d1 <= 0 || d2 <= 0 {
+		return 0
+	}
+	return math.Sqrt(wavelength * d1 * d2 / (d1 + d2))
+}
+
+// analyze the first Fresnel zone along profile
+//
+// the first and last points of profile are the two antennas
+func ComputeFresnel(profile []ProfilePoint, opts FresnelOptions) FresnelAnalysis {
+	first, last := profile[0], profile[len(profile)-1]
+	total := last.Distance - first.Distance
+	wavelength := SpeedOfLight / opts.Frequency
+	radius := opts.KFactor * EarthRadius
+	from := first.Elevation + opts.FromHeight
+	to := last.Elevation + opts.ToHeight
+
+	analysis := FresnelAnalysis{
+		Distance:     total,
+		Frequency:    opts.Frequency,
+		Wavelength:   wavelength,
+		KFactor:      opts.KFactor,
+		LineOfSight:  true,
+		FresnelClear: true,
+		Points:       make([]FresnelPoint, 0, len(profile)),
+	}
+	worst := math.Inf(1)
+	for _, p := range profile {
+		d := p.Distance - first.Distance
+		pt := FresnelPoint{
+			Latitude:      p.Latitude,
+			Longitude:     p.Longitude,
+			Distance:      d,
+			Elevation:     p.Elevation,
+			EarthBulge:    EarthBulge(d, total, radius),
+			LineOfSight:   from + (to-from)*d/total,
+			FresnelRadius: FresnelRadius(wavelength, d, total-d),
+		}
+		pt.Clearance = pt.LineOfSight - (pt.Elevation + pt.EarthBulge)
+		if pt.FresnelRadius > 0 {
+			pt.ClearancePercent = pt.Clearance / pt.FresnelRadius * 100
+		}
+		if math.IsNaN(p.Elevation) {
+			// voids have no terrain to clear, and NaN does not encode as json
+			pt.Elevation, pt.Clearance, pt.ClearancePercent = 0, 0, 0
+			analysis.Points = append(analysis.Points, pt)
+			continue
+		}
+		analysis.Points = append(analysis.Points, pt)
+		if pt.FresnelRadius == 0 {
+			continue
+		}
+		if pt.Clearance < 0 {
+			analysis.LineOfSight = false
+		}
+		if pt.ClearancePercent < MinFresnelClearance*100 {
+			analysis.FresnelClear = false
+		}
+		if pt.ClearancePercent < worst {
+			worst = pt.ClearancePercent
+			analysis.Worst = &pt
+		}
+	}
+	return analysis
+}
+
+// size of the svg chart in pixels
+const (
+	svgWidth   = 800
+	svgHeight  = 300
+	svgPadding = 40
+)
+
+// write a chart of the link to w as an svg
+//
+// the terrain (with earth bulge) is filled, the sight line is solid and the first Fresnel zone is dashed
+func FresnelToSVG(w io.Writer, analysis FresnelAnalysis) error {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range analysis.Points {
+		lo = math.Min(lo, math.Min(p.Elevation+p.EarthBulge, p.LineOfSight-p.FresnelRadius))
+		hi = math.Max(hi, math.Max(p.Elevation+p.EarthBulge, p.LineOfSight+p.FresnelRadius))
+	}
+	if hi <= lo {
+		hi = lo + 1
+	}
+	x := func(d float64) float64 {
+		return svgPadding + d/math.Max(analysis.Distance, 1)*(svgWidth-2*svgPadding)
+	}
+	y := func(h float64) float64 {
+		return svgHeight - svgPadding - (h-lo)/(hi-lo)*(svgHeight-2*svgPadding)
+	}
+	path := func(h func(p FresnelPoint) float64) string {
+		s := ""
+		for i, p := range analysis.Points {
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			s += fmt.Sprintf("%s%.1f,%.1f ", cmd, x(p.Distance), y(h(p)))
+		}
+		return s
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", svgWidth, svgHeight, svgWidth, svgHeight)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	terrain := path(func(p FresnelPoint) float64 { return p.Elevation + p.EarthBulge })
+	fmt.Fprintf(bw, `<path d="%sL%.1f,%.1f L%.1f,%.1f Z" fill="#b5a27a" stroke="#6b5a36"/>`+"\n",
+		terrain, x(analysis.Distance), y(lo), x(0), y(lo))
+	fmt.Fprintf(bw, `<path d="%s" fill="none" stroke="#1f77b4" stroke-dasharray="4 3"/>`+"\n",
+		path(func(p FresnelPoint) float64 { return p.LineOfSight + p.FresnelRadius }))
+	fmt.Fprintf(bw, `<path d="%s" fill="none" stroke="#1f77b4" stroke-dasharray="4 3"/>`+"\n",
+		path(func(p FresnelPoint) float64 { return p.LineOfSight - p.FresnelRadius }))
+	fmt.Fprintf(bw, `<path d="%s" fill="none" stroke="#d62728" stroke-width="1.5"/>`+"\n",
+		path(func(p FresnelPoint) float64 { return p.LineOfSight }))
+	caption := fmt.Sprintf("%.2f km, %.0f MHz, k=%.2f", analysis.Distance/1000, analysis.Frequency/1e6, analysis.KFactor)
+	if analysis.Worst != nil {
+		caption += fmt.Sprintf(", worst clearance %.0f%% of F1", analysis.Worst.ClearancePercent)
+	}
+	fmt.Fprintf(bw, `<text x="%d" y="20" font-family="sans-serif" font-size="12">%s</text>`+"\n", svgPadding, caption)
+	fmt.Fprintf(bw, `<text x="%d" y="%d" font-family="sans-serif" font-size="10">%.0f m</text>`+"\n", 2, svgPadding, hi)
+	fmt.Fprintf(bw, `<text x="%d" y="%d" font-family="sans-serif" font-size="10">%.0f m</text>`+"\n", 2, svgHeight-svgPadding, lo)
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
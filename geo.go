@@ -0,0 +1,76 @@
+package elevation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// mean earth radius in meters
+const EarthRadius = 6371008.8
+
+// a single lat,lng pair
+type Coordinate struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// parse a coordinate of the form lat,lng
+func ParseCoordinate(s string) (Coordinate, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Coordinate{}, fmt.Errorf("invalid coordinate: %s (expected lat,lng)", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("invalid coordinate: %s: %v", s, err)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("invalid coordinate: %s: %v", s, err)
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return Coordinate{}, fmt.Errorf("invalid coordinate: %s: out of range", s)
+	}
+	return Coordinate{Latitude: lat, Longitude: lng}, nil
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180.0
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180.0 / math.Pi
+}
+
+// great circle distance in meters between two points
+func Haversine(lat1 float64, lng1 float64, lat2 float64, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// the point distance meters from lat,lng along the initial bearing (degrees clockwise from north)
+func Destination(lat float64, lng float64, bearing float64, distance float64) (float64, float64) {
+	phi1, lambda1 := toRadians(lat), toRadians(lng)
+	theta := toRadians(bearing)
+	delta := distance / EarthRadius
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1), math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+	return toDegrees(phi2), math.Mod(toDegrees(lambda2)+540, 360) - 180
+}
+
+// bounding box containing every point within radius meters of lat,lng
+func RadiusBoundingBox(lat float64, lng float64, radius float64) BoundingBox {
+	dLat := toDegrees(radius / EarthRadius)
+	dLng := dLat / math.Max(math.Cos(toRadians(lat)), 0.01)
+	return BoundingBox{
+		MinLatitude:  math.Max(lat-dLat, -90),
+		MinLongitude: math.Max(lng-dLng, -180),
+		MaxLatitude:  math.Min(lat+dLat, 90),
+		MaxLongitude: math.Min(lng+dLng, 180),
+	}
+}
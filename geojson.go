@@ -0,0 +1,68 @@
+package elevation
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// a minimal set of GeoJSON (RFC 7946) types
+//
+// positions are always [longitude, latitude]
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string         `json:"type"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// a single [longitude, latitude] position
+type Position [2]float64
+
+func NewFeatureCollection(features []Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+func NewFeature(geometry Geometry, properties map[string]any) Feature {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	return Feature{Type: "Feature", Geometry: geometry, Properties: properties}
+}
+
+func NewPoint(lat float64, lng float64) Geometry {
+	return Geometry{Type: "Point", Coordinates: Position{lng, lat}}
+}
+
+func NewLineString(positions []Position) Geometry {
+	return Geometry{Type: "LineString", Coordinates: positions}
+}
+
+func NewMultiLineString(lines [][]Position) Geometry {
+	return Geometry{Type: "MultiLineString", Coordinates: lines}
+}
+
+// rings[0] is the exterior ring, the rest are holes
+func NewPolygon(rings [][]Position) Geometry {
+	return Geometry{Type: "Polygon", Coordinates: rings}
+}
+
+func NewMultiPolygon(polygons [][][]Position) Geometry {
+	return Geometry{Type: "MultiPolygon", Coordinates: polygons}
+}
+
+// write the feature collection to w as json
+func WriteGeoJSON(w io.Writer, fc FeatureCollection) error {
+	return json.NewEncoder(w).Encode(fc)
+}
@@ -0,0 +1,236 @@
+package elevation
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// tiff field types
+const (
+	tiffShort  = 3
+	tiffLong   = 4
+	tiffDouble = 12
+	tiffASCII  = 2
+)
+
+// geotiff keys
+const (
+	gtModelTypeGeoKey    = 1024
+	gtRasterTypeGeoKey   = 1025
+	geographicTypeGeoKey = 2048
+	modelTypeGeographic  = 2
+	rasterPixelIsArea    = 1
+	epsgWGS84            = 4326
+)
+
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	// encoded value, little endian
+	data []byte
+}
+
+func tiffShorts(tag uint16, vals ...uint16) tiffEntry {
+	data := make([]byte, 2*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint16(data[2*i:], v)
+	}
+	return tiffEntry{tag: tag, typ: tiffShort, count: uint32(len(vals)), data: data}
+}
+
+func tiffLongs(tag uint16, vals ...uint32) tiffEntry {
+	data := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(data[4*i:], v)
+	}
+	return tiffEntry{tag: tag, typ: tiffLong, count: uint32(len(vals)), data: data}
+}
+
+func tiffDoubles(tag uint16, vals ...float64) tiffEntry {
+	data := make([]byte, 8*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(v))
+	}
+	return tiffEntry{tag: tag, typ: tiffDouble, count: uint32(len(vals)), data: data}
+}
+
+func tiffString(tag uint16, s string) tiffEntry {
+	data := append([]byte(s), 0)
+	return tiffEntry{tag: tag, typ: tiffASCII, count: uint32(len(data)), data: data}
+}
+
+// write the grid to w as a single band float32 GeoTIFF in EPSG:4326
+//
+// voids are written as NoData
+func GridToGeoTIFF(w io.Writer, g *Grid) error {
+	s := float64(g.Spacing)
+	return writeTIFF(w, g.Cols, g.Rows, g.Data,
+		tiffDoubles(33550, s, s, 0),
+		tiffDoubles(33922, 0, 0, 0, g.West-s/2, g.North+s/2, 0),
+		tiffShorts(34735,
+			1, 1, 0, 3,
+			gtModelTypeGeoKey, 0, 1, modelTypeGeographic,
+			gtRasterTypeGeoKey, 0, 1, rasterPixelIsArea,
+			geographicTypeGeoKey, 0, 1, epsgWGS84,
+		),
+		tiffString(42113, "-32768"), // GDAL_NODATA
+	)
+}
+
+// write width x height values, row by row from the top, to w as a plain single band float32 TIFF
+//
+// NaN is written as NoData
+func FloatsToTIFF(w io.Writer, width int, height int, data []float64) error {
+	return writeTIFF(w, width, height, data)
+}
+
+// write a single strip, uncompressed float32 tiff with the extra tags
+func writeTIFF(w io.Writer, width int, height int, data []float64, extra ...tiffEntry) error {
+	const headerSize = 8
+	imageSize := uint32(width * height * 4)
+
+	entries := append([]tiffEntry{
+		tiffLongs(256, uint32(width)),
+		tiffLongs(257, uint32(height)),
+		tiffShorts(258, 32),
+		tiffShorts(259, 1), // no compression
+		tiffShorts(262, 1), // black is zero
+		tiffLongs(273, headerSize),
+		tiffShorts(277, 1),
+		tiffLongs(278, uint32(height)),
+		tiffLongs(279, imageSize),
+		tiffShorts(284, 1),
+		tiffShorts(339, 3), // ieee float
+	}, extra...)
+	sort.Slice(entries, func(i int, j int) bool { return entries[i].tag < entries[j].tag })
+
+	ifdOffset := uint32(headerSize) + imageSize
+	ifdSize := uint32(2 + 12*len(entries) + 4)
+	// values that don't fit in the 4 byte entry field go after the ifd
+	extraOffset := ifdOffset + ifdSize
+
+	bw := bufio.NewWriter(w)
+	header := []byte{'I', 'I', 42, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(header[4:], ifdOffset)
+	bw.Write(header)
+
+	buf := make([]byte, 4)
+	for _, v := range data {
+		if math.IsNaN(v) {
+			v = NoData
+		}
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+		bw.Write(buf)
+	}
+
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(buf, uint16(len(entries)))
+	bw.Write(buf[:2])
+	values := []byte{}
+	for _, e := range entries {
+		binary.LittleEndian.PutUint16(entry[0:], e.tag)
+		binary.LittleEndian.PutUint16(entry[2:], e.typ)
+		binary.LittleEndian.PutUint32(entry[4:], e.count)
+		clear(entry[8:])
+		if len(e.data) <= 4 {
+			copy(entry[8:], e.data)
+		} else {
+			binary.LittleEndian.PutUint32(entry[8:], extraOffset+uint32(len(values)))
+			values = append(values, e.data...)
+			// keep offsets word aligned
+			if len(values)%2 == 1 {
+				values = append(values, 0)
+			}
+		}
+		bw.Write(entry)
+	}
+	binary.LittleEndian.PutUint32(buf, 0) // no more ifds
+	bw.Write(buf)
+	bw.Write(values)
+	return bw.Flush()
+}
+
+// read the values of a plain float32 TIFF written by FloatsToTIFF
+//
+// returns width, height and the values row by row from the top. NoData is read as NaN
+func TIFFToFloats(data []byte) (int, int, []float64, error) {
+	t, err := parseTIFF(data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	width, height, values, err := t.decode()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	for i, v := range values {
+		if v == NoData {
+			values[i] = math.NaN()
+		}
+	}
+	return width, height, values, nil
+}
+
+// more geotiff keys
+const (
+	projectedCSTypeGeoKey = 3072
+	modelTypeProjected    = 1
+	rasterPixelIsPoint    = 2
+)
+
+// read the first band of a GeoTIFF in geographic coordinates (e.g. Copernicus DEM, ASTER GDEM)
+//
+// the GDAL_NODATA value is read as a void. projected and rotated rasters are not supported
+func ReadGeoTIFF(r io.Reader) (*Grid, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseTIFF(data)
+	if err != nil {
+		return nil, err
+	}
+	scale, tiepoint := t.floats(33550), t.floats(33922)
+	if len(scale) < 2 || len(tiepoint) < 6 {
+		if _, ok := t.fields[34264]; ok {
+			return nil, fmt.Errorf("unsupported geotiff: model transformation")
+		}
+		return nil, fmt.Errorf("not a geotiff: missing pixel scale or tiepoint")
+	}
+	rasterType := rasterPixelIsArea
+	if keys := t.floats(34735); len(keys) >= 4 {
+		for i := 4; i+3 < len(keys) && i < 4+4*int(keys[3]); i += 4 {
+			// only keys stored inline (location 0)
+			if keys[i+1] != 0 {
+				continue
+			}
+			switch int(keys[i]) {
+			case gtModelTypeGeoKey:
+				if int(keys[i+3]) == modelTypeProjected {
+					return nil, fmt.Errorf("unsupported geotiff: projected coordinates")
+				}
+			case projectedCSTypeGeoKey:
+				return nil, fmt.Errorf("unsupported geotiff: projected coordinates")
+			case gtRasterTypeGeoKey:
+				rasterType = int(keys[i+3])
+			}
+		}
+	}
+
+	width, height, values, err := t.decode()
+	if err != nil {
+		return nil, err
+	}
+	dx, dy := scale[0], scale[1]
+	// the tiepoint maps raster i,j to x,y
+	west, north := tiepoint[3]-tiepoint[0]*dx, tiepoint[4]+tiepoint[1]*dy
+	if rasterType != rasterPixelIsPoint {
+		// the corner of the first pixel
+		west, north = west+dx/2, north-dy/2
+	}
+	return newGridFromRaster(north, west, dx, dy, width, height, values)
+}
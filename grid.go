@@ -0,0 +1,329 @@
+package elevation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// a lat,lng aligned rectangle
+type BoundingBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// parse a bounding box of the form minLat,minLng,maxLat,maxLng
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("invalid bounding box: %s (expected minLat,minLng,maxLat,maxLng)", s)
+	}
+	vals := [4]float64{}
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid bounding box: %s: %v", s, err)
+		}
+		vals[i] = v
+	}
+	bbox := BoundingBox{MinLatitude: vals[0], MinLongitude: vals[1], MaxLatitude: vals[2], MaxLongitude: vals[3]}
+	return bbox, bbox.Validate()
+}
+
+func (b BoundingBox) Validate() error {
+	if b.MinLatitude >= b.MaxLatitude || b.MinLongitude >= b.MaxLongitude {
+		return fmt.Errorf("invalid bounding box: min must be less than max")
+	}
+	if b.MinLatitude < -90 || b.MaxLatitude > 90 || b.MinLongitude < -180 || b.MaxLongitude > 180 {
+		return fmt.Errorf("invalid bounding box: out of range")
+	}
+	return nil
+}
+
+// grow the box by d degrees on every side
+func (b BoundingBox) Buffer(d float64) BoundingBox {
+	return BoundingBox{
+		MinLatitude:  b.MinLatitude - d,
+		MinLongitude: b.MinLongitude - d,
+		MaxLatitude:  b.MaxLatitude + d,
+		MaxLongitude: b.MaxLongitude + d,
+	}
+}
+
+func (b BoundingBox) Contains(lat float64, lng float64) bool {
+	return lat >= b.MinLatitude && lat <= b.MaxLatitude && lng >= b.MinLongitude && lng <= b.MaxLongitude
+}
+
+// used to absorb floating point error when snapping to the grid
+const gridEpsilon = 1e-9
+
+// a regular lat,lng grid of elevations
+//
+// row 0 is the northern most row, col 0 is the western most column.
+// voids are stored as NaN
+type Grid struct {
+	// latitude of row 0
+	North float64
+	// longitude of col 0
+	West    float64
+	Spacing Spacing
+	Rows    int
+	Cols    int
+	Data    []float64
+}
+
+// create a grid aligned to spacing that covers bbox
+//
+// every cell starts as a void
+func NewGrid(bbox BoundingBox, spacing Spacing) *Grid {
+	north, west, rows, cols := gridShape(bbox, spacing)
+	return newGrid(north, west, spacing, rows, cols)
+}
+
+// number of cells a grid covering bbox would have, without allocating it
+func GridCells(bbox BoundingBox, spacing Spacing) int {
+	rows, cols := GridSize(bbox, spacing)
+	return rows * cols
+}
+
+// rows and columns of a grid covering bbox, without allocating it
+func GridSize(bbox BoundingBox, spacing Spacing) (int, int) {
+	_, _, rows, cols := gridShape(bbox, spacing)
+	return rows, cols
+}
+
+func gridShape(bbox BoundingBox, spacing Spacing) (float64, float64, int, int) {
+	s := float64(spacing)
+	north := math.Floor(bbox.MaxLatitude/s+gridEpsilon) * s
+	west := math.Ceil(bbox.MinLongitude/s-gridEpsilon) * s
+	rows := int(math.Floor((north-bbox.MinLatitude)/s+gridEpsilon)) + 1
+	cols := int(math.Floor((bbox.MaxLongitude-west)/s+gridEpsilon)) + 1
+	return north, west, max(rows, 0), max(cols, 0)
+}
+
+func newGrid(north float64, west float64, spacing Spacing, rows int, cols int) *Grid {
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = math.NaN()
+	}
+	return &Grid{North: north, West: west, Spacing: spacing, Rows: rows, Cols: cols, Data: data}
+}
+
+// create a grid of the same shape, filled with voids
+func (g *Grid) Like() *Grid {
+	return newGrid(g.North, g.West, g.Spacing, g.Rows, g.Cols)
+}
+
+// create a grid covering bbox and fill it with the records
+//
+// records outside of the grid are ignored
+func NewGridFromRecords(bbox BoundingBox, spacing Spacing, records []HGTRecord) *Grid {
+	g := NewGrid(bbox, spacing)
+	for _, r := range records {
+		row, col := g.Cell(r.Latitude, r.Longitude)
+		if g.InBounds(row, col) {
+			g.Set(row, col, r.Elevation)
+		}
+	}
+	return g
+}
+
+func (g *Grid) InBounds(row int, col int) bool {
+	return row >= 0 && row < g.Rows && col >= 0 && col < g.Cols
+}
+
+// elevation at row,col. NaN if void or out of bounds
+func (g *Grid) At(row int, col int) float64 {
+	if !g.InBounds(row, col) {
+		return math.NaN()
+	}
+	return g.Data[row*g.Cols+col]
+}
+
+func (g *Grid) Set(row int, col int, v float64) {
+	g.Data[row*g.Cols+col] = v
+}
+
+// true if the cell is in bounds and not a void
+func (g *Grid) Valid(row int, col int) bool {
+	return !math.IsNaN(g.At(row, col))
+}
+
+func (g *Grid) Latitude(row int) float64 {
+	return g.North - float64(row)*float64(g.Spacing)
+}
+
+func (g *Grid) Longitude(col int) float64 {
+	return g.West + float64(col)*float64(g.Spacing)
+}
+
+// nearest row,col to lat,lng. may be out of bounds
+func (g *Grid) Cell(lat float64, lng float64) (int, int) {
+	s := float64(g.Spacing)
+	row := int(math.Round((g.North - lat) / s))
+	col := int(math.Round((lng - g.West) / s))
+	return row, col
+}
+
+// fractional row,col of lat,lng
+func (g *Grid) Position(lat float64, lng float64) (float64, float64) {
+	s := float64(g.Spacing)
+	return (g.North - lat) / s, (lng - g.West) / s
+}
+
+// bilinear interpolation at lat,lng
+//
+// NaN if any of the surrounding cells are void
+func (g *Grid) Interpolate(lat float64, lng float64) float64 {
+	r, c := g.Position(lat, lng)
+	r0, c0 := int(math.Floor(r)), int(math.Floor(c))
+	fr, fc := r-float64(r0), c-float64(c0)
+	// a float error off a cell is on the cell, so a void next to it is not mixed in
+	if fr > 1-gridEpsilon {
+		r0, fr = r0+1, 0
+	}
+	if fc > 1-gridEpsilon {
+		c0, fc = c0+1, 0
+	}
+	if fr < gridEpsilon {
+		fr = 0
+	}
+	if fc < gridEpsilon {
+		fc = 0
+	}
+	top := g.At(r0, c0)*(1-fc) + g.At(r0, c0+1)*fc
+	if fc == 0 {
+		top = g.At(r0, c0)
+	}
+	if fr == 0 {
+		return top
+	}
+	bottom := g.At(r0+1, c0)*(1-fc) + g.At(r0+1, c0+1)*fc
+	if fc == 0 {
+		bottom = g.At(r0+1, c0)
+	}
+	return top*(1-fr) + bottom*fr
+}
+
+// approximate size in meters of the cell at row
+//
+// dx shrinks with latitude, dy is constant
+func (g *Grid) CellSize(row int) (float64, float64) {
+	s := toRadians(float64(g.Spacing))
+	dy := EarthRadius * s
+	dx := dy * math.Cos(toRadians(g.Latitude(row)))
+	return dx, dy
+}
+
+// copy out the cells that fall inside of bbox
+func (g *Grid) Crop(bbox BoundingBox) *Grid {
+	out := NewGrid(bbox, g.Spacing)
+	r0, c0 := g.Cell(out.North, out.West)
+	for row := range out.Rows {
+		for col := range out.Cols {
+			out.Set(row, col, g.At(r0+row, c0+col))
+		}
+	}
+	return out
+}
+
+// fill the voids of g inside bbox by interpolating src
+//
+// for combining data of different resolutions
+func (g *Grid) FillVoids(src *Grid, bbox BoundingBox) {
+	for row := range g.Rows {
+		lat := g.Latitude(row)
+		if lat < bbox.MinLatitude-gridEpsilon || lat > bbox.MaxLatitude+gridEpsilon {
+			continue
+		}
+		for col := range g.Cols {
+			lng := g.Longitude(col)
+			if lng < bbox.MinLongitude-gridEpsilon || lng > bbox.MaxLongitude+gridEpsilon || !math.IsNaN(g.At(row, col)) {
+				continue
+			}
+			g.Set(row, col, src.Interpolate(lat, lng))
+		}
+	}
+}
+
+// bounding box of the cell centers
+func (g *Grid) BoundingBox() BoundingBox {
+	return BoundingBox{
+		MinLatitude:  g.Latitude(g.Rows - 1),
+		MinLongitude: g.West,
+		MaxLatitude:  g.North,
+		MaxLongitude: g.Longitude(g.Cols - 1),
+	}
+}
+
+// min and max of all non void cells
+//
+// returns NaN, NaN if the grid is entirely void
+func (g *Grid) MinMax() (float64, float64) {
+	lo, hi := math.NaN(), math.NaN()
+	for _, v := range g.Data {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(lo) || v < lo {
+			lo = v
+		}
+		if math.IsNaN(hi) || v > hi {
+			hi = v
+		}
+	}
+	return lo, hi
+}
+
+// resample the grid to a new spacing using bilinear interpolation
+//
+// the new grid starts at the same north west cell and covers as much of the original as fits
+func (g *Grid) Resample(spacing Spacing) *Grid {
+	ratio := float64(g.Spacing) / float64(spacing)
+	rows := int(math.Floor(float64(g.Rows-1)*ratio+gridEpsilon)) + 1
+	cols := int(math.Floor(float64(g.Cols-1)*ratio+gridEpsilon)) + 1
+	out := newGrid(g.North, g.West, spacing, rows, cols)
+	for row := range rows {
+		for col := range cols {
+			out.Set(row, col, g.Interpolate(out.Latitude(row), out.Longitude(col)))
+		}
+	}
+	return out
+}
+
+// value at lat,lng using method
+//
+// bicubic falls back to bilinear when any of the 16 surrounding cells are void
+func (g *Grid) Sample(lat float64, lng float64, method InterpolationMethod) float64 {
+	switch method {
+	case NearestNeighbor:
+		return g.At(g.Cell(lat, lng))
+	case Bicubic:
+		r, c := g.Position(lat, lng)
+		r0, c0 := int(math.Floor(r)), int(math.Floor(c))
+		var cols [4]float64
+		for i := range 4 {
+			row := r0 - 1 + i
+			cols[i] = catmullRom(g.At(row, c0-1), g.At(row, c0), g.At(row, c0+1), g.At(row, c0+2), c-float64(c0))
+		}
+		if v := catmullRom(cols[0], cols[1], cols[2], cols[3], r-float64(r0)); !math.IsNaN(v) {
+			return v
+		}
+		return g.Interpolate(lat, lng)
+	default:
+		return g.Interpolate(lat, lng)
+	}
+}
+
+// Catmull-Rom spline between p1 and p2 at t in [0, 1]
+func catmullRom(p0 float64, p1 float64, p2 float64, p3 float64, t float64) float64 {
+	t2 := t * t
+	t3 := t2 * t
+	return 0.5 * (2*p1 +
+		(-p0+p2)*t +
+		(2*p0-5*p1+4*p2-p3)*t2 +
+		(-p0+3*p1-3*p2+p3)*t3)
+}
@@ -0,0 +1,149 @@
+package elevation
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// parameters for a 16 bit heightmap
+type HeightmapOptions struct {
+	// size of the image in pixels. 0 uses the size of the grid
+	Width  int
+	Height int
+	// elevations mapped to 1 and 65535. NaN uses the range of the data
+	Min float64
+	Max float64
+	// how the grid is sampled at each pixel
+	Resampling InterpolationMethod
+}
+
+var DefaultHeightmapOptions = HeightmapOptions{Min: math.NaN(), Max: math.NaN(), Resampling: Bilinear}
+
+// pixel value reserved for voids
+const HeightmapNoData = 0
+
+// describes how to georeference a heightmap and turn pixel values back into elevations
+//
+// elevation = offset + value * scale, for every value but NoData
+type HeightmapMetadata struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	CRS    string `json:"crs"`
+	// outer edges of the image
+	West  float64 `json:"west"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	North float64 `json:"north"`
+	// size of a pixel in degrees
+	PixelWidth  float64 `json:"pixel_width"`
+	PixelHeight float64 `json:"pixel_height"`
+	// approximate size of a pixel in meters at the center of the image
+	PixelWidthMeters  float64 `json:"pixel_width_meters"`
+	PixelHeightMeters float64 `json:"pixel_height_meters"`
+	Min               float64 `json:"min"`
+	Max               float64 `json:"max"`
+	Offset            float64 `json:"offset"`
+	Scale             float64 `json:"scale"`
+	Resampling        string  `json:"resampling"`
+	// value of void pixels, never used for an elevation
+	NoData int `json:"nodata"`
+	// number of void pixels
+	Voids int `json:"voids"`
+}
+
+// render the grid as a 16 bit grayscale image
+//
+// elevations outside of min,max are clamped. voids are HeightmapNoData
+func (g *Grid) Heightmap(opts HeightmapOptions) (*image.Gray16, HeightmapMetadata, error) {
+	switch opts.Resampling {
+	case NearestNeighbor, Bilinear, Bicubic:
+	default:
+		return nil, HeightmapMetadata{}, fmt.Errorf("invalid resampling method: %s", opts.Resampling)
+	}
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = g.Cols
+	}
+	if height == 0 {
+		height = g.Rows
+	}
+	if width < 1 || height < 1 {
+		return nil, HeightmapMetadata{}, fmt.Errorf("invalid size: %dx%d", width, height)
+	}
+	lo, hi := opts.Min, opts.Max
+	if math.IsNaN(lo) || math.IsNaN(hi) {
+		dataLo, dataHi := g.MinMax()
+		if math.IsNaN(dataLo) {
+			return nil, HeightmapMetadata{}, fmt.Errorf("grid is entirely void")
+		}
+		if math.IsNaN(lo) {
+			lo = dataLo
+		}
+		if math.IsNaN(hi) {
+			hi = dataHi
+		}
+	}
+	if hi < lo {
+		return nil, HeightmapMetadata{}, fmt.Errorf("max %f is below min %f", hi, lo)
+	}
+	// 0 is kept for voids, min is 1
+	scale := (hi - lo) / 65534
+	if scale == 0 {
+		scale = 1
+	}
+
+	s := float64(g.Spacing)
+	bbox := g.BoundingBox()
+	meta := HeightmapMetadata{
+		Width:      width,
+		Height:     height,
+		CRS:        "EPSG:4326",
+		West:       bbox.MinLongitude - s/2,
+		South:      bbox.MinLatitude - s/2,
+		East:       bbox.MaxLongitude + s/2,
+		North:      bbox.MaxLatitude + s/2,
+		Min:        lo,
+		Max:        hi,
+		Offset:     lo - scale,
+		Scale:      scale,
+		Resampling: string(opts.Resampling),
+		NoData:     HeightmapNoData,
+	}
+	meta.PixelWidth = (meta.East - meta.West) / float64(width)
+	meta.PixelHeight = (meta.North - meta.South) / float64(height)
+	meta.PixelHeightMeters = toRadians(meta.PixelHeight) * EarthRadius
+	meta.PixelWidthMeters = toRadians(meta.PixelWidth) * EarthRadius * math.Cos(toRadians((meta.North+meta.South)/2))
+
+	img := image.NewGray16(image.Rect(0, 0, width, height))
+	for y := range height {
+		lat := math.Max(math.Min(meta.North-(float64(y)+0.5)*meta.PixelHeight, bbox.MaxLatitude), bbox.MinLatitude)
+		for x := range width {
+			lng := math.Max(math.Min(meta.West+(float64(x)+0.5)*meta.PixelWidth, bbox.MaxLongitude), bbox.MinLongitude)
+			v := g.Sample(lat, lng, opts.Resampling)
+			if math.IsNaN(v) {
+				meta.Voids++
+				img.SetGray16(x, y, color.Gray16{Y: HeightmapNoData})
+				continue
+			}
+			img.SetGray16(x, y, color.Gray16{Y: uint16(math.Max(1, math.Min(65535, 1+math.Round((v-lo)/scale))))})
+		}
+	}
+	return img, meta, nil
+}
+
+// write the heightmap to w as a 16 bit grayscale png
+func HeightmapToPNG(w io.Writer, img *image.Gray16) error {
+	return png.Encode(w, img)
+}
+
+// write the heightmap metadata to w as indented json
+func HeightmapMetadataToJSON(w io.Writer, meta HeightmapMetadata) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(meta)
+}
@@ -4,39 +4,100 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
+// an hgt product, named as in the usgs downloads
+type Product string
+
+const (
+	// SRTM 1 arc-second global
+	SRTMGL1 Product = "SRTMGL1"
+	// SRTM 3 arc-second global
+	SRTMGL3 Product = "SRTMGL3"
+	// NASADEM, the reprocessed SRTM data at 1 arc-second
+	NASADEM Product = "NASADEM"
+)
+
+// spacing of the hgt files of the product, 0 if unknown
+func (p Product) Spacing() Spacing {
+	switch p {
+	case SRTMGL1, NASADEM:
+		return SRTM1
+	case SRTMGL3:
+		return SRTM3
+	default:
+		return 0
+	}
+}
+
+// a 1° tile of a dem and the product it was read from
+type DEMTile struct {
+	// south west corner
+	Latitude  int
+	Longitude int
+	// empty when unknown (e.g. not read from an hgt)
+	Product Product
+	Spacing Spacing
+}
+
 // ensure title name is of the correct format
 //
-// should look like S15W040
-var tileNamePattern = regexp.MustCompile(`^[NS]\d{2}[EW]\d{3}$`)
+// should look like S15W040, optionally with the product and extensions it is downloaded with
+// (N00E006.SRTMGL1.hgt.zip, N00E006.SRTMGL3.hgt, NASADEM_HGT_n00e006.zip). case is ignored
+var tileNamePattern = regexp.MustCompile(`(?i)^(?:(NASADEM)_HGT_)?([NS]\d{2}[EW]\d{3})(?:\.(SRTMGL[13]))?(?:\.hgt)?(?:\.zip)?$`)
 
-// return lat, lng, error
+// parse a tile name, see tileNamePattern
 //
-// tile name is of the form S15W040
-func ParseTileName(name string) (int, int, error) {
-	if !tileNamePattern.MatchString(name) {
-		return -1, -1, fmt.Errorf("invalid title name: %s", name)
+// the product (and spacing) is only set when the name includes it
+func ParseTile(name string) (DEMTile, error) {
+	m := tileNamePattern.FindStringSubmatch(name)
+	if m == nil || (m[1] != "" && m[3] != "") {
+		return DEMTile{}, fmt.Errorf("invalid title name: %s", name)
 	}
-	latStr := name[1:3]
-	lat, err := strconv.Atoi(latStr)
+	tile := strings.ToUpper(m[2])
+	lat, err := strconv.Atoi(tile[1:3])
 	if err != nil {
-		return -1, -1, err
+		return DEMTile{}, err
 	}
-	lngStr := name[4:7]
-	lng, err := strconv.Atoi(lngStr)
+	lng, err := strconv.Atoi(tile[4:7])
 	if err != nil {
-		return -1, -1, err
+		return DEMTile{}, err
 	}
-	if name[0] == 'S' {
+	if tile[0] == 'S' {
 		lat = -lat
 	}
-	if name[3] == 'W' {
+	if tile[3] == 'W' {
 		lng = -lng
 	}
-	return lat, lng, nil
+	product := Product(strings.ToUpper(m[1] + m[3]))
+	return DEMTile{Latitude: lat, Longitude: lng, Product: product, Spacing: product.Spacing()}, nil
+}
+
+// return lat, lng, error
+//
+// tile name is of the form S15W040, see ParseTile for the accepted variants
+func ParseTileName(name string) (int, int, error) {
+	t, err := ParseTile(name)
+	if err != nil {
+		return -1, -1, err
+	}
+	return t.Latitude, t.Longitude, nil
+}
+
+// spacing of an hgt file from its size in bytes
+func HGTSpacing(size int) (Spacing, error) {
+	switch size / 2 {
+	case 1201 * 1201:
+		return SRTM3, nil // 3-arcsecond data
+	case 3601 * 3601:
+		return SRTM1, nil // 1-arcsecond data
+	default:
+		return 0, fmt.Errorf("unexpected file size: %d bytes (%d points)", size, size/2)
+	}
 }
 
 // process hgt content
@@ -47,28 +108,17 @@ func ProcessHGT(r io.Reader, lat int, lng int) ([]HGTRecord, error) {
 	if err != nil {
 		return nil, err
 	}
-	size := int64(len(data))
-	totalPoints := int(size / 2)
-	var gridSize int
-	switch totalPoints {
-	case 1201 * 1201:
-		gridSize = 1201 // 3-arcsecond data
-	case 3601 * 3601:
-		gridSize = 3601 // 1-arcsecond data
-	default:
-		return nil, fmt.Errorf("unexpected file size: %d bytes (%d points)", size, totalPoints)
+	spacing, err := HGTSpacing(len(data))
+	if err != nil {
+		return nil, err
 	}
+	step := float64(spacing)
+	gridSize := int(math.Round(1/step)) + 1
+	totalPoints := gridSize * gridSize
 	elevationData := make([]int16, totalPoints)
 	for i := range totalPoints {
 		elevationData[i] = int16(binary.BigEndian.Uint16(data[i*2 : i*2+2]))
 	}
-	// Calculate step size based on grid size
-	var step float64
-	if gridSize == 1201 {
-		step = 1.0 / 1200.0 // 3-arcsecond = 1/1200 degree
-	} else {
-		step = 1.0 / 3600.0 // 1-arcsecond = 1/3600 degree
-	}
 
 	records := make([]HGTRecord, gridSize*gridSize)
 	i := 0
@@ -87,5 +137,5 @@ func ProcessHGT(r io.Reader, lat int, lng int) ([]HGTRecord, error) {
 			i++
 		}
 	}
-	return records, nil
+	return records[:i], nil
 }
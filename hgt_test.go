@@ -0,0 +1,69 @@
+package elevation
+
+import "testing"
+
+func TestParseTile(t *testing.T) {
+	tests := []struct {
+		name string
+		want DEMTile
+	}{
+		{"N00E006", DEMTile{Latitude: 0, Longitude: 6}},
+		{"S15W040", DEMTile{Latitude: -15, Longitude: -40}},
+		{"s15w040", DEMTile{Latitude: -15, Longitude: -40}},
+		{"N46E008.hgt", DEMTile{Latitude: 46, Longitude: 8}},
+		{"N46E008.HGT", DEMTile{Latitude: 46, Longitude: 8}},
+		{"N46E008.hgt.zip", DEMTile{Latitude: 46, Longitude: 8}},
+		{"N00E006.SRTMGL1.hgt.zip", DEMTile{Latitude: 0, Longitude: 6, Product: SRTMGL1, Spacing: SRTM1}},
+		{"N00E006.SRTMGL1.hgt", DEMTile{Latitude: 0, Longitude: 6, Product: SRTMGL1, Spacing: SRTM1}},
+		{"n00e006.srtmgl1.hgt.zip", DEMTile{Latitude: 0, Longitude: 6, Product: SRTMGL1, Spacing: SRTM1}},
+		{"N00E006.SRTMGL3.hgt", DEMTile{Latitude: 0, Longitude: 6, Product: SRTMGL3, Spacing: SRTM3}},
+		{"S01W180.SRTMGL3.hgt.zip", DEMTile{Latitude: -1, Longitude: -180, Product: SRTMGL3, Spacing: SRTM3}},
+		{"NASADEM_HGT_n00e006.zip", DEMTile{Latitude: 0, Longitude: 6, Product: NASADEM, Spacing: SRTM1}},
+		{"NASADEM_HGT_n00e006", DEMTile{Latitude: 0, Longitude: 6, Product: NASADEM, Spacing: SRTM1}},
+		{"nasadem_hgt_S12W077.hgt", DEMTile{Latitude: -12, Longitude: -77, Product: NASADEM, Spacing: SRTM1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTile(tt.name)
+			if err != nil {
+				t.Fatalf("ParseTile(%q): %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTile(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTileInvalid(t *testing.T) {
+	for _, name := range []string{
+		"",
+		"N0E006",
+		"N00E06",
+		"X00E006",
+		"N00E006.tif",
+		"N00E006.SRTMGL2.hgt",
+		"N00E006.hgt.SRTMGL1",
+		"N00E006.zip.hgt",
+		"N00E006_SRTMGL1.hgt",
+		"NASADEM_n00e006.zip",
+		"NASADEM_HGT_n00e006.SRTMGL1.hgt",
+		"SRTMGL1_HGT_n00e006.zip",
+		"data/N00E006.hgt",
+		" N00E006",
+	} {
+		if tile, err := ParseTile(name); err == nil {
+			t.Errorf("ParseTile(%q) = %+v, want an error", name, tile)
+		}
+	}
+}
+
+func TestParseTileName(t *testing.T) {
+	lat, lng, err := ParseTileName("NASADEM_HGT_s15w040.zip")
+	if err != nil || lat != -15 || lng != -40 {
+		t.Errorf("ParseTileName = %d, %d, %v, want -15, -40, nil", lat, lng, err)
+	}
+	if _, _, err := ParseTileName("N00E006.tif"); err == nil {
+		t.Error("ParseTileName(N00E006.tif): want an error")
+	}
+}
@@ -0,0 +1,82 @@
+package elevation
+
+import "math"
+
+// azimuths (degrees) blended together for a multi-directional hillshade
+var multiDirectionalAzimuths = []float64{225, 270, 315, 360}
+
+// lighting parameters for a hillshade
+type HillshadeOptions struct {
+	// direction of the sun in degrees clockwise from north
+	Azimuth float64
+	// angle of the sun in degrees above the horizon
+	Altitude float64
+	// vertical exaggeration
+	ZFactor float64
+	// blend light from several azimuths instead of a single sun
+	MultiDirectional bool
+}
+
+// the conventional north west sun at 45 degrees
+var DefaultHillshadeOptions = HillshadeOptions{
+	Azimuth:  315,
+	Altitude: 45,
+	ZFactor:  1,
+}
+
+// shaded relief for the whole grid
+//
+// values are in the range 0 (shadow) to 255 (fully lit). edge cells and cells touching voids are left as voids
+func (g *Grid) Hillshade(opts HillshadeOptions) *Grid {
+	out := g.Like()
+	for row := range g.Rows {
+		for col := range g.Cols {
+			dzdx, dzdy, ok := g.Gradient(row, col, Horn)
+			if !ok {
+				continue
+			}
+			dzdx *= opts.ZFactor
+			dzdy *= opts.ZFactor
+			var shade float64
+			if opts.MultiDirectional {
+				shade = multiDirectionalShade(dzdx, dzdy, opts.Altitude)
+			} else {
+				shade = illumination(dzdx, dzdy, opts.Azimuth, opts.Altitude)
+			}
+			out.Set(row, col, math.Round(shade*255))
+		}
+	}
+	return out
+}
+
+// cosine of the angle between the surface normal and the sun, clamped to 0
+func illumination(dzdx float64, dzdy float64, azimuth float64, altitude float64) float64 {
+	az, alt := toRadians(azimuth), toRadians(altitude)
+	// sun vector in (east, north, up)
+	sx, sy, sz := math.Sin(az)*math.Cos(alt), math.Cos(az)*math.Cos(alt), math.Sin(alt)
+	// surface normal in (east, north, up). dz/dy increases to the south
+	nx, ny, nz := -dzdx, dzdy, 1.0
+	norm := math.Sqrt(nx*nx + ny*ny + nz*nz)
+	return math.Max(0, (nx*sx+ny*sy+nz*sz)/norm)
+}
+
+// weighted blend of several light sources
+//
+// each light is weighted by how side on it is to the slope, which keeps
+// ridges visible regardless of their orientation
+func multiDirectionalShade(dzdx float64, dzdy float64, altitude float64) float64 {
+	aspect := aspectDegrees(dzdx, dzdy)
+	total, weights := 0.0, 0.0
+	for _, az := range multiDirectionalAzimuths {
+		w := 1.0
+		if aspect != FlatAspect {
+			w = math.Pow(math.Sin(toRadians(aspect-az)), 2)
+		}
+		total += w * illumination(dzdx, dzdy, az, altitude)
+		weights += w
+	}
+	if weights == 0 {
+		return illumination(dzdx, dzdy, DefaultHillshadeOptions.Azimuth, altitude)
+	}
+	return total / weights
+}
@@ -0,0 +1,155 @@
+package elevation
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// parameters for a horizon panorama
+type HorizonOptions struct {
+	// height (m) of the eye above the ground
+	EyeHeight float64
+	// how far (m) to look for the horizon
+	Radius float64
+	// degrees between each azimuth
+	Step float64
+	// account for the curvature of the earth
+	Curvature bool
+	// coefficient of refraction. only used when Curvature is set
+	Refraction float64
+}
+
+var DefaultHorizonOptions = HorizonOptions{
+	EyeHeight:  1.7,
+	Radius:     40000,
+	Step:       0.1,
+	Curvature:  true,
+	Refraction: StandardRefraction,
+}
+
+// the highest terrain in a single direction
+//
+// angle is the elevation angle (degrees) above the horizontal. distance is 0 if nothing was found
+type HorizonPoint struct {
+	Azimuth   float64 `json:"azimuth"`
+	Angle     float64 `json:"angle"`
+	Distance  float64 `json:"distance"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Elevation float64 `json:"elevation"`
+}
+
+type Horizon struct {
+	Observer Coordinate `json:"observer"`
+	// ground elevation (m) at the observer
+	Elevation float64        `json:"elevation"`
+	EyeHeight float64        `json:"eye_height"`
+	Points    []HorizonPoint `json:"points"`
+}
+
+// compute the skyline seen from observer
+func (g *Grid) Horizon(observer Coordinate, opts HorizonOptions) (Horizon, error) {
+	ground := g.Interpolate(observer.Latitude, observer.Longitude)
+	if math.IsNaN(ground) {
+		return Horizon{}, fmt.Errorf("no data at observer %f, %f", observer.Latitude, observer.Longitude)
+	}
+	h := Horizon{Observer: observer, Elevation: ground, EyeHeight: opts.EyeHeight}
+	n := int(math.Round(360 / opts.Step))
+	h.Points = make([]HorizonPoint, n)
+	for i := range n {
+		h.Points[i] = g.HorizonAt(observer, ground+opts.EyeHeight, float64(i)*opts.Step, opts)
+	}
+	return h, nil
+}
+
+// find the highest elevation angle along azimuth as seen from observer at eye elevation (m)
+//
+// samples start at the grid resolution and get sparser with distance
+func (g *Grid) HorizonAt(observer Coordinate, eye float64, azimuth float64, opts HorizonOptions) HorizonPoint {
+	cell := EarthRadius * toRadians(float64(g.Spacing))
+	radius := EffectiveEarthRadius(opts.Refraction)
+	best := HorizonPoint{Azimuth: azimuth, Angle: -90}
+	for d := cell; d <= opts.Radius; d += math.Max(cell, d/500) {
+		lat, lng := Destination(observer.Latitude, observer.Longitude, azimuth, d)
+		z := g.Interpolate(lat, lng)
+		if math.IsNaN(z) {
+			continue
+		}
+		dz := z - eye
+		if opts.Curvature {
+			dz -= d * d / (2 * radius)
+		}
+		if angle := toDegrees(math.Atan2(dz, d)); angle > best.Angle {
+			best = HorizonPoint{Azimuth: azimuth, Angle: angle, Distance: d, Latitude: lat, Longitude: lng, Elevation: z}
+		}
+	}
+	return best
+}
+
+// vertical extent (degrees) of a rendered panorama
+func (h Horizon) angleRange() (float64, float64) {
+	lo, hi := 0.0, 0.0
+	for _, p := range h.Points {
+		lo, hi = math.Min(lo, p.Angle), math.Max(hi, p.Angle)
+	}
+	// leave some sky above the highest point
+	return lo - 1, hi + 2
+}
+
+// size of rendered panoramas in pixels
+const (
+	panoramaWidth  = 1800
+	panoramaHeight = 300
+)
+
+// write the skyline to w as an svg silhouette
+//
+// the x axis runs from north through east, south and west. the dashed line is the horizontal
+func HorizonToSVG(w io.Writer, h Horizon) error {
+	lo, hi := h.angleRange()
+	x := func(az float64) float64 { return az / 360 * panoramaWidth }
+	y := func(angle float64) float64 { return (hi - angle) / (hi - lo) * panoramaHeight }
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", panoramaWidth, panoramaHeight, panoramaWidth, panoramaHeight)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#cfe6f7"/>`+"\n")
+	fmt.Fprintf(bw, `<path d="M0,%d `, panoramaHeight)
+	for _, p := range h.Points {
+		fmt.Fprintf(bw, "L%.1f,%.1f ", x(p.Azimuth), y(p.Angle))
+	}
+	if len(h.Points) > 0 {
+		fmt.Fprintf(bw, "L%d,%.1f ", panoramaWidth, y(h.Points[0].Angle))
+	}
+	fmt.Fprintf(bw, `L%d,%d Z" fill="#4a5a48"/>`+"\n", panoramaWidth, panoramaHeight)
+	fmt.Fprintf(bw, `<line x1="0" y1="%.1f" x2="%d" y2="%.1f" stroke="#888" stroke-dasharray="4 4"/>`+"\n", y(0), panoramaWidth, y(0))
+	for i, label := range []string{"N", "E", "S", "W"} {
+		fmt.Fprintf(bw, `<text x="%.1f" y="14" font-family="sans-serif" font-size="12">%s</text>`+"\n", x(float64(i)*90)+2, label)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// write the skyline to w as a png silhouette
+func HorizonToPNG(w io.Writer, h Horizon) error {
+	lo, hi := h.angleRange()
+	img := image.NewNRGBA(image.Rect(0, 0, panoramaWidth, panoramaHeight))
+	sky := color.NRGBA{R: 0xcf, G: 0xe6, B: 0xf7, A: 255}
+	ground := color.NRGBA{R: 0x4a, G: 0x5a, B: 0x48, A: 255}
+	for px := range panoramaWidth {
+		i := px * len(h.Points) / panoramaWidth
+		top := (hi - h.Points[i].Angle) / (hi - lo) * panoramaHeight
+		for py := range panoramaHeight {
+			if float64(py) >= top {
+				img.SetNRGBA(px, py, ground)
+			} else {
+				img.SetNRGBA(px, py, sky)
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,319 @@
+package elevation
+
+import (
+	"container/heap"
+	"math"
+)
+
+// D8 flow directions using the ESRI encoding
+const (
+	FlowEast      = 1
+	FlowSouthEast = 2
+	FlowSouth     = 4
+	FlowSouthWest = 8
+	FlowWest      = 16
+	FlowNorthWest = 32
+	FlowNorth     = 64
+	FlowNorthEast = 128
+	// the cell drains off the edge of the grid or into a void
+	FlowNone = 0
+)
+
+// row,col offsets for each D8 direction
+var d8 = [8]struct {
+	dr   int
+	dc   int
+	code float64
+}{
+	{0, 1, FlowEast},
+	{1, 1, FlowSouthEast},
+	{1, 0, FlowSouth},
+	{1, -1, FlowSouthWest},
+	{0, -1, FlowWest},
+	{-1, -1, FlowNorthWest},
+	{-1, 0, FlowNorth},
+	{-1, 1, FlowNorthEast},
+}
+
+// row,col offsets for a D8 flow direction code
+func FlowOffset(code float64) (int, int, bool) {
+	for _, d := range d8 {
+		if d.code == code {
+			return d.dr, d.dc, true
+		}
+	}
+	return 0, 0, false
+}
+
+// the derived rasters of a hydrological analysis
+type Hydrology struct {
+	// the original elevations
+	DEM *Grid
+	// depressionless elevations
+	Filled *Grid
+	// D8 flow direction codes
+	Direction *Grid
+	// number of cells (including itself) that drain through each cell
+	Accumulation *Grid
+}
+
+// fill depressions, then compute flow directions and accumulation
+func (g *Grid) Hydrology() Hydrology {
+	filled := g.FillDepressions()
+	direction := filled.FlowDirection()
+	return Hydrology{
+		DEM:          g,
+		Filled:       filled,
+		Direction:    direction,
+		Accumulation: direction.FlowAccumulation(),
+	}
+}
+
+type floodCell struct {
+	row  int
+	col  int
+	elev float64
+}
+
+type floodQueue []floodCell
+
+func (q floodQueue) Len() int           { return len(q) }
+func (q floodQueue) Less(i, j int) bool { return q[i].elev < q[j].elev }
+func (q floodQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *floodQueue) Push(x any)        { *q = append(*q, x.(floodCell)) }
+func (q *floodQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// fill depressions using priority-flood (Barnes et al. 2014)
+//
+// flats are given a tiny gradient so that every cell drains to the edge of the grid or a void
+func (g *Grid) FillDepressions() *Grid {
+	filled := g.Like()
+	closed := make([]bool, len(g.Data))
+	q := &floodQueue{}
+
+	// seed with every cell on the edge of the grid or next to a void
+	for row := range g.Rows {
+		for col := range g.Cols {
+			if !g.Valid(row, col) || !g.isBoundary(row, col) {
+				continue
+			}
+			heap.Push(q, floodCell{row, col, g.At(row, col)})
+			closed[row*g.Cols+col] = true
+		}
+	}
+	for q.Len() > 0 {
+		c := heap.Pop(q).(floodCell)
+		filled.Set(c.row, c.col, c.elev)
+		for _, d := range d8 {
+			r, cc := c.row+d.dr, c.col+d.dc
+			if !g.Valid(r, cc) || closed[r*g.Cols+cc] {
+				continue
+			}
+			closed[r*g.Cols+cc] = true
+			elev := g.At(r, cc)
+			if next := math.Nextafter(c.elev, math.Inf(1)); elev < next {
+				elev = next
+			}
+			heap.Push(q, floodCell{r, cc, elev})
+		}
+	}
+	return filled
+}
+
+// true if the cell is on the edge of the grid or touches a void
+func (g *Grid) isBoundary(row int, col int) bool {
+	for _, d := range d8 {
+		if !g.Valid(row+d.dr, col+d.dc) {
+			return true
+		}
+	}
+	return false
+}
+
+// D8 flow direction of every cell
+//
+// each cell drains to the neighbor with the steepest drop. cells with no lower neighbor are FlowNone
+func (g *Grid) FlowDirection() *Grid {
+	out := g.Like()
+	for row := range g.Rows {
+		dx, dy := g.CellSize(row)
+		diag := math.Hypot(dx, dy)
+		for col := range g.Cols {
+			z := g.At(row, col)
+			if math.IsNaN(z) {
+				continue
+			}
+			best, code := 0.0, float64(FlowNone)
+			for _, d := range d8 {
+				n := g.At(row+d.dr, col+d.dc)
+				if math.IsNaN(n) {
+					continue
+				}
+				dist := diag
+				if d.dr == 0 {
+					dist = dx
+				} else if d.dc == 0 {
+					dist = dy
+				}
+				if drop := (z - n) / dist; drop > best {
+					best, code = drop, d.code
+				}
+			}
+			out.Set(row, col, code)
+		}
+	}
+	return out
+}
+
+// visit every cell of a flow direction grid in upstream to downstream order
+func (g *Grid) walkDownstream(visit func(row int, col int, downRow int, downCol int, ok bool)) {
+	indegree := make([]int, len(g.Data))
+	for row := range g.Rows {
+		for col := range g.Cols {
+			if r, c, ok := g.downstream(row, col); ok {
+				indegree[r*g.Cols+c]++
+			}
+		}
+	}
+	queue := []int{}
+	for i, v := range g.Data {
+		if !math.IsNaN(v) && indegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		row, col := i/g.Cols, i%g.Cols
+		r, c, ok := g.downstream(row, col)
+		visit(row, col, r, c, ok)
+		if !ok {
+			continue
+		}
+		j := r*g.Cols + c
+		indegree[j]--
+		if indegree[j] == 0 {
+			queue = append(queue, j)
+		}
+	}
+}
+
+// the cell that row,col drains into in a flow direction grid
+func (g *Grid) downstream(row int, col int) (int, int, bool) {
+	v := g.At(row, col)
+	if math.IsNaN(v) {
+		return 0, 0, false
+	}
+	dr, dc, ok := FlowOffset(v)
+	if !ok || !g.Valid(row+dr, col+dc) {
+		return 0, 0, false
+	}
+	return row + dr, col + dc, true
+}
+
+// number of cells draining through each cell of a flow direction grid, including itself
+func (g *Grid) FlowAccumulation() *Grid {
+	out := g.Like()
+	for i, v := range g.Data {
+		if !math.IsNaN(v) {
+			out.Data[i] = 1
+		}
+	}
+	g.walkDownstream(func(row int, col int, r int, c int, ok bool) {
+		if ok {
+			out.Set(r, c, out.At(r, c)+out.At(row, col))
+		}
+	})
+	return out
+}
+
+// a stream segment between two confluences
+type Stream struct {
+	// Strahler order
+	Order int
+	// accumulation (cells) at the downstream end
+	Accumulation float64
+	Positions    []Position
+}
+
+// extract streams where the accumulation is at least threshold cells
+//
+// each stream runs from a source or confluence to the next confluence or outlet
+func (h Hydrology) Streams(threshold float64) []Stream {
+	dir, acc := h.Direction, h.Accumulation
+	isStream := func(row int, col int) bool {
+		return acc.Valid(row, col) && acc.At(row, col) >= threshold
+	}
+
+	// count of stream cells flowing into each cell, and the Strahler order of each stream cell
+	inflows := make([]int, len(dir.Data))
+	order := make([]int, len(dir.Data))
+	maxCount := make([]int, len(dir.Data))
+	dir.walkDownstream(func(row int, col int, r int, c int, ok bool) {
+		if !isStream(row, col) {
+			return
+		}
+		i := row*dir.Cols + col
+		if order[i] == 0 {
+			order[i] = 1
+		} else if maxCount[i] > 1 {
+			order[i]++
+		}
+		if !ok || !isStream(r, c) {
+			return
+		}
+		j := r*dir.Cols + c
+		inflows[j]++
+		if order[i] > order[j] {
+			order[j], maxCount[j] = order[i], 1
+		} else if order[i] == order[j] {
+			maxCount[j]++
+		}
+	})
+
+	streams := []Stream{}
+	for row := range dir.Rows {
+		for col := range dir.Cols {
+			i := row*dir.Cols + col
+			// streams start at sources and confluences
+			if !isStream(row, col) || inflows[i] == 1 {
+				continue
+			}
+			s := Stream{Order: order[i], Positions: []Position{{dir.Longitude(col), dir.Latitude(row)}}}
+			r, c := row, col
+			for {
+				s.Accumulation = acc.At(r, c)
+				nr, nc, ok := dir.downstream(r, c)
+				if !ok || !isStream(nr, nc) {
+					break
+				}
+				s.Positions = append(s.Positions, Position{dir.Longitude(nc), dir.Latitude(nr)})
+				r, c = nr, nc
+				if inflows[r*dir.Cols+c] > 1 {
+					break
+				}
+			}
+			if len(s.Positions) > 1 {
+				streams = append(streams, s)
+			}
+		}
+	}
+	return streams
+}
+
+// convert streams to a GeoJSON feature collection of LineStrings
+func StreamsToGeoJSON(streams []Stream) FeatureCollection {
+	features := make([]Feature, len(streams))
+	for i, s := range streams {
+		features[i] = NewFeature(NewLineString(s.Positions), map[string]any{
+			"order":        s.Order,
+			"accumulation": s.Accumulation,
+		})
+	}
+	return NewFeatureCollection(features)
+}
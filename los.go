@@ -0,0 +1,98 @@
+package elevation
+
+import "math"
+
+// typical coefficient of atmospheric refraction for visible light
+const StandardRefraction = 0.13
+
+// parameters for a line of sight check
+type LineOfSightOptions struct {
+	// height (m) of the observer above the ground
+	ObserverHeight float64
+	// height (m) of the target above the ground
+	TargetHeight float64
+	// account for the curvature of the earth
+	Curvature bool
+	// coefficient of refraction. only used when Curvature is set
+	Refraction float64
+}
+
+// the point where the terrain blocks the line of sight
+//
+// clearance is the height (m) of the sight line above the terrain, so it is negative for an obstruction
+type Obstruction struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Elevation float64 `json:"elevation"`
+	Distance  float64 `json:"distance"`
+	Clearance float64 `json:"clearance"`
+}
+
+type LineOfSight struct {
+	Visible bool `json:"visible"`
+	// distance (m) between the observer and the target
+	Distance       float64      `json:"distance"`
+	Observer       ProfilePoint `json:"observer"`
+	Target         ProfilePoint `json:"target"`
+	ObserverHeight float64      `json:"observer_height"`
+	TargetHeight   float64      `json:"target_height"`
+	// the first point where the terrain blocks the sight line. nil if visible
+	Obstruction *Obstruction `json:"obstruction"`
+	// the point with the least clearance along the path
+	MinClearance Obstruction `json:"min_clearance"`
+}
+
+// radius (m) of the earth as seen by a ray bent by refraction k
+func EffectiveEarthRadius(k float64) float64 {
+	return EarthRadius / (1 - k)
+}
+
+// height (m) the earth rises above the chord at distance d along a path of length total
+func EarthBulge(d float64, total float64, radius float64) float64 {
+	return d * (total - d) / (2 * radius)
+}
+
+// check if the target is visible from the observer along profile
+//
+// the first and last points of profile are the observer and the target
+func ComputeLineOfSight(profile []ProfilePoint, opts LineOfSightOptions) LineOfSight {
+	observer, target := profile[0], profile[len(profile)-1]
+	total := target.Distance - observer.Distance
+	from := observer.Elevation + opts.ObserverHeight
+	to := target.Elevation + opts.TargetHeight
+	radius := EffectiveEarthRadius(opts.Refraction)
+
+	los := LineOfSight{
+		Visible:        true,
+		Distance:       total,
+		Observer:       observer,
+		Target:         target,
+		ObserverHeight: opts.ObserverHeight,
+		TargetHeight:   opts.TargetHeight,
+		MinClearance:   Obstruction{Clearance: math.Inf(1)},
+	}
+	for _, p := range profile[1 : len(profile)-1] {
+		if math.IsNaN(p.Elevation) {
+			continue
+		}
+		d := p.Distance - observer.Distance
+		terrain := p.Elevation
+		if opts.Curvature {
+			terrain += EarthBulge(d, total, radius)
+		}
+		line := from + (to-from)*d/total
+		clearance := line - terrain
+		o := Obstruction{Latitude: p.Latitude, Longitude: p.Longitude, Elevation: p.Elevation, Distance: d, Clearance: clearance}
+		if clearance < los.MinClearance.Clearance {
+			los.MinClearance = o
+		}
+		if clearance < 0 && los.Obstruction == nil {
+			los.Visible = false
+			los.Obstruction = &o
+		}
+	}
+	if math.IsInf(los.MinClearance.Clearance, 1) {
+		los.MinClearance = Obstruction{}
+	}
+	return los
+}
@@ -0,0 +1,213 @@
+package elevation
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// parameters for a terrain mesh
+type MeshOptions struct {
+	// vertical exaggeration
+	Exaggeration float64
+	// thickness (m) of a solid base below the lowest point. 0 for just the surface
+	Base float64
+}
+
+var DefaultMeshOptions = MeshOptions{Exaggeration: 1}
+
+// a triangulated surface in local metric coordinates
+//
+// x is meters east and y is meters north of the center of the grid. z is meters
+// above the bottom of the mesh, so the lowest point of the terrain is at Base.
+// triangles wind counter clockwise seen from outside
+type Mesh struct {
+	Origin Coordinate
+	// elevation (m) at z = 0
+	Datum     float64
+	Vertices  [][3]float32
+	Triangles [][3]uint32
+}
+
+// triangulate the grid with two triangles per cell
+//
+// voids are set to the lowest elevation so that the surface stays closed
+func (g *Grid) Mesh(opts MeshOptions) (Mesh, error) {
+	if g.Rows < 2 || g.Cols < 2 {
+		return Mesh{}, fmt.Errorf("grid is too small to mesh: %dx%d", g.Rows, g.Cols)
+	}
+	lo, _ := g.MinMax()
+	if math.IsNaN(lo) {
+		return Mesh{}, fmt.Errorf("grid is entirely void")
+	}
+	bbox := g.BoundingBox()
+	origin := Coordinate{Latitude: (bbox.MinLatitude + bbox.MaxLatitude) / 2, Longitude: (bbox.MinLongitude + bbox.MaxLongitude) / 2}
+	plane := Plane{Origin: origin}
+	m := Mesh{Origin: origin, Datum: lo - opts.Base/opts.Exaggeration}
+
+	for row := range g.Rows {
+		for col := range g.Cols {
+			z := g.At(row, col)
+			if math.IsNaN(z) {
+				z = lo
+			}
+			x, y := plane.offset(g.Latitude(row), g.Longitude(col))
+			m.Vertices = append(m.Vertices, [3]float32{float32(x), float32(y), float32((z - m.Datum) * opts.Exaggeration)})
+		}
+	}
+	idx := func(row int, col int) uint32 { return uint32(row*g.Cols + col) }
+	for row := range g.Rows - 1 {
+		for col := range g.Cols - 1 {
+			nw, ne := idx(row, col), idx(row, col+1)
+			sw, se := idx(row+1, col), idx(row+1, col+1)
+			m.Triangles = append(m.Triangles, [3]uint32{sw, se, ne}, [3]uint32{sw, ne, nw})
+		}
+	}
+	if opts.Base > 0 {
+		m.addBase(g, idx)
+	}
+	return m, nil
+}
+
+// close the mesh with walls down to z = 0 and a flat bottom
+func (m *Mesh) addBase(g *Grid, idx func(int, int) uint32) {
+	// the edge of the surface counter clockwise from the north west corner
+	edge := []uint32{}
+	for row := range g.Rows - 1 {
+		edge = append(edge, idx(row, 0))
+	}
+	for col := range g.Cols - 1 {
+		edge = append(edge, idx(g.Rows-1, col))
+	}
+	for row := g.Rows - 1; row > 0; row-- {
+		edge = append(edge, idx(row, g.Cols-1))
+	}
+	for col := g.Cols - 1; col > 0; col-- {
+		edge = append(edge, idx(0, col))
+	}
+
+	bottom := make([]uint32, len(edge))
+	for i, v := range edge {
+		bottom[i] = uint32(len(m.Vertices))
+		p := m.Vertices[v]
+		m.Vertices = append(m.Vertices, [3]float32{p[0], p[1], 0})
+	}
+	center := uint32(len(m.Vertices))
+	m.Vertices = append(m.Vertices, [3]float32{0, 0, 0})
+
+	for i := range edge {
+		j := (i + 1) % len(edge)
+		m.Triangles = append(m.Triangles,
+			[3]uint32{edge[i], bottom[i], bottom[j]},
+			[3]uint32{edge[i], bottom[j], edge[j]},
+			[3]uint32{center, bottom[j], bottom[i]},
+		)
+	}
+}
+
+// unit normal of triangle t
+func (m Mesh) normal(t [3]uint32) [3]float32 {
+	a, b, c := m.Vertices[t[0]], m.Vertices[t[1]], m.Vertices[t[2]]
+	ux, uy, uz := float64(b[0]-a[0]), float64(b[1]-a[1]), float64(b[2]-a[2])
+	vx, vy, vz := float64(c[0]-a[0]), float64(c[1]-a[1]), float64(c[2]-a[2])
+	nx, ny, nz := uy*vz-uz*vy, uz*vx-ux*vz, ux*vy-uy*vx
+	l := math.Sqrt(nx*nx + ny*ny + nz*nz)
+	if l == 0 {
+		return [3]float32{}
+	}
+	return [3]float32{float32(nx / l), float32(ny / l), float32(nz / l)}
+}
+
+// write the mesh to w as binary STL
+func MeshToSTL(w io.Writer, m Mesh) error {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 80)
+	copy(header, fmt.Sprintf("terrain %.6f,%.6f", m.Origin.Latitude, m.Origin.Longitude))
+	bw.Write(header)
+	binary.Write(bw, binary.LittleEndian, uint32(len(m.Triangles)))
+	for _, t := range m.Triangles {
+		binary.Write(bw, binary.LittleEndian, m.normal(t))
+		for _, v := range t {
+			binary.Write(bw, binary.LittleEndian, m.Vertices[v])
+		}
+		binary.Write(bw, binary.LittleEndian, uint16(0))
+	}
+	return bw.Flush()
+}
+
+// write the mesh to w as Wavefront OBJ
+func MeshToOBJ(w io.Writer, m Mesh) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# terrain centered on %f,%f, z = 0 at %.2f m\n", m.Origin.Latitude, m.Origin.Longitude, m.Datum)
+	fmt.Fprintln(bw, "o terrain")
+	for _, v := range m.Vertices {
+		fmt.Fprintf(bw, "v %g %g %g\n", v[0], v[1], v[2])
+	}
+	for _, t := range m.Triangles {
+		fmt.Fprintf(bw, "f %d %d %d\n", t[0]+1, t[1]+1, t[2]+1)
+	}
+	return bw.Flush()
+}
+
+// gltf constants
+const (
+	gltfFloat        = 5126
+	gltfUnsignedInt  = 5125
+	gltfArrayBuffer  = 34962
+	gltfElementArray = 34963
+	gltfTriangles    = 4
+)
+
+// write the mesh to w as a glTF 2.0 json file with an embedded buffer
+//
+// glTF is y up, so positions are written as (east, up, south)
+func MeshToGLTF(w io.Writer, m Mesh) error {
+	buf := make([]byte, 0, 12*len(m.Vertices)+12*len(m.Triangles))
+	lo := [3]float32{float32(math.Inf(1)), float32(math.Inf(1)), float32(math.Inf(1))}
+	hi := [3]float32{float32(math.Inf(-1)), float32(math.Inf(-1)), float32(math.Inf(-1))}
+	for _, v := range m.Vertices {
+		p := [3]float32{v[0], v[2], -v[1]}
+		for k := range 3 {
+			lo[k], hi[k] = min(lo[k], p[k]), max(hi[k], p[k])
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(p[k]))
+		}
+	}
+	positions := len(buf)
+	for _, t := range m.Triangles {
+		for _, v := range t {
+			buf = binary.LittleEndian.AppendUint32(buf, v)
+		}
+	}
+
+	doc := map[string]any{
+		"asset":  map[string]any{"version": "2.0", "generator": "elevation"},
+		"scene":  0,
+		"scenes": []any{map[string]any{"nodes": []int{0}}},
+		"nodes":  []any{map[string]any{"mesh": 0, "name": "terrain"}},
+		"meshes": []any{map[string]any{
+			"primitives": []any{map[string]any{
+				"attributes": map[string]int{"POSITION": 0},
+				"indices":    1,
+				"mode":       gltfTriangles,
+			}},
+		}},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": gltfFloat, "count": len(m.Vertices), "type": "VEC3", "min": lo, "max": hi},
+			map[string]any{"bufferView": 1, "componentType": gltfUnsignedInt, "count": 3 * len(m.Triangles), "type": "SCALAR"},
+		},
+		"bufferViews": []any{
+			map[string]any{"buffer": 0, "byteOffset": 0, "byteLength": positions, "target": gltfArrayBuffer},
+			map[string]any{"buffer": 0, "byteOffset": positions, "byteLength": len(buf) - positions, "target": gltfElementArray},
+		},
+		"buffers": []any{map[string]any{
+			"byteLength": len(buf),
+			"uri":        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf),
+		}},
+		"extras": map[string]any{"origin": m.Origin, "datum": m.Datum},
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
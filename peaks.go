@@ -0,0 +1,189 @@
+package elevation
+
+import (
+	"math"
+	"sort"
+)
+
+// a summit with its prominence and isolation
+type Peak struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Elevation float64 `json:"elevation"`
+	// height (m) of the peak above its key col
+	Prominence float64 `json:"prominence"`
+	// the highest col connecting the peak to higher ground
+	KeyCol          Coordinate `json:"key_col"`
+	KeyColElevation float64    `json:"key_col_elevation"`
+	// distance (m) to the nearest higher ground. -1 if there is none in the grid
+	Isolation float64 `json:"isolation"`
+	// true if no higher ground is connected to the peak in the grid, in which case prominence and
+	// isolation are lower bounds measured to the lowest connected point and the edge of the grid.
+	// voids can cut the grid into several parts, each with its own highest peak
+	Highest bool `json:"highest"`
+}
+
+// union-find over cell indexes
+type components struct {
+	parent []int
+	// the highest cell of each component
+	peak []int
+}
+
+func (c *components) find(i int) int {
+	for c.parent[i] != i {
+		c.parent[i] = c.parent[c.parent[i]]
+		i = c.parent[i]
+	}
+	return i
+}
+
+// find every peak with at least minProminence meters of prominence
+//
+// cells are flooded from the top down. a new component starts at every local maximum and
+// when two components meet the lower one's key col has been found. peaks are sorted by prominence
+func (g *Grid) Peaks(minProminence float64) []Peak {
+	order := make([]int, 0, len(g.Data))
+	for i, v := range g.Data {
+		if !math.IsNaN(v) {
+			order = append(order, i)
+		}
+	}
+	if len(order) == 0 {
+		return []Peak{}
+	}
+	sort.Slice(order, func(a int, b int) bool { return g.Data[order[a]] > g.Data[order[b]] })
+
+	c := &components{parent: make([]int, len(g.Data)), peak: make([]int, len(g.Data))}
+	for i := range c.parent {
+		c.parent[i] = -1
+	}
+	peaks := []Peak{}
+	for _, i := range order {
+		row, col := i/g.Cols, i%g.Cols
+		c.parent[i] = i
+		c.peak[i] = i
+
+		// the distinct components that this cell touches
+		roots := []int{}
+		for _, d := range d8 {
+			r, cc := row+d.dr, col+d.dc
+			if !g.InBounds(r, cc) || c.parent[r*g.Cols+cc] == -1 {
+				continue
+			}
+			root := c.find(r*g.Cols + cc)
+			seen := false
+			for _, x := range roots {
+				seen = seen || x == root
+			}
+			if !seen {
+				roots = append(roots, root)
+			}
+		}
+		if len(roots) == 0 {
+			continue
+		}
+		// the component with the highest peak survives, the rest end at this col
+		sort.Slice(roots, func(a int, b int) bool { return g.Data[c.peak[roots[a]]] > g.Data[c.peak[roots[b]]] })
+		for _, root := range roots[1:] {
+			p := c.peak[root]
+			if prominence := g.Data[p] - g.Data[i]; prominence >= minProminence {
+				peaks = append(peaks, g.newPeak(p, i, false))
+			}
+			c.parent[root] = roots[0]
+		}
+		c.parent[i] = roots[0]
+	}
+
+	// the highest peak of each component never meets higher ground, measure it to the lowest
+	// cell of its component. cells are in descending order so the last one seen is the lowest
+	lowest := map[int]int{}
+	roots := []int{}
+	for _, i := range order {
+		root := c.find(i)
+		if _, ok := lowest[root]; !ok {
+			roots = append(roots, root)
+		}
+		lowest[root] = i
+	}
+	for _, root := range roots {
+		if top := c.peak[root]; g.Data[top]-g.Data[lowest[root]] >= minProminence {
+			peaks = append(peaks, g.newPeak(top, lowest[root], true))
+		}
+	}
+
+	for i := range peaks {
+		peaks[i].Isolation = g.isolation(peaks[i])
+	}
+	sort.SliceStable(peaks, func(a int, b int) bool { return peaks[a].Prominence > peaks[b].Prominence })
+	return peaks
+}
+
+func (g *Grid) newPeak(peak int, col int, highest bool) Peak {
+	pr, pc := peak/g.Cols, peak%g.Cols
+	cr, cc := col/g.Cols, col%g.Cols
+	return Peak{
+		Latitude:        g.Latitude(pr),
+		Longitude:       g.Longitude(pc),
+		Elevation:       g.Data[peak],
+		Prominence:      g.Data[peak] - g.Data[col],
+		KeyCol:          Coordinate{Latitude: g.Latitude(cr), Longitude: g.Longitude(cc)},
+		KeyColElevation: g.Data[col],
+		Highest:         highest,
+	}
+}
+
+// distance to the nearest cell higher than the peak
+//
+// rings of cells are searched outward from the peak. once a higher cell is found, the
+// search continues until no closer cell could exist
+func (g *Grid) isolation(p Peak) float64 {
+	row, col := g.Cell(p.Latitude, p.Longitude)
+	_, dy := g.CellSize(row)
+	best := math.Inf(1)
+	for k := 1; k < max(g.Rows, g.Cols); k++ {
+		// every cell on ring k is at least k cells away, and cells are narrowest at the poleward edge of the ring
+		dxNorth, _ := g.CellSize(row - k)
+		dxSouth, _ := g.CellSize(row + k)
+		if float64(k)*math.Min(dy, math.Min(dxNorth, dxSouth)) > best {
+			break
+		}
+		check := func(r int, c int) {
+			v := g.At(r, c)
+			if math.IsNaN(v) || v <= p.Elevation {
+				return
+			}
+			best = math.Min(best, Haversine(p.Latitude, p.Longitude, g.Latitude(r), g.Longitude(c)))
+		}
+		for c := col - k; c <= col+k; c++ {
+			check(row-k, c)
+			check(row+k, c)
+		}
+		for r := row - k + 1; r < row+k; r++ {
+			check(r, col-k)
+			check(r, col+k)
+		}
+	}
+	if math.IsInf(best, 1) {
+		return -1
+	}
+	return best
+}
+
+// convert peaks to a GeoJSON feature collection of Points
+func PeaksToGeoJSON(peaks []Peak) FeatureCollection {
+	features := make([]Feature, len(peaks))
+	for i, p := range peaks {
+		features[i] = NewFeature(NewPoint(p.Latitude, p.Longitude), map[string]any{
+			"rank":              i + 1,
+			"elevation":         p.Elevation,
+			"prominence":        p.Prominence,
+			"key_col_latitude":  p.KeyCol.Latitude,
+			"key_col_longitude": p.KeyCol.Longitude,
+			"key_col_elevation": p.KeyColElevation,
+			"isolation":         p.Isolation,
+			"highest":           p.Highest,
+		})
+	}
+	return NewFeatureCollection(features)
+}
@@ -0,0 +1,36 @@
+package elevation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPeaksSplitByVoids(t *testing.T) {
+	// two hills on either side of a column of voids
+	g := NewGrid(BoundingBox{MinLatitude: 46, MinLongitude: 8, MaxLatitude: 46 + 4*float64(SRTM3), MaxLongitude: 8 + 10*float64(SRTM3)}, SRTM3)
+	for row := range g.Rows {
+		for col := range g.Cols {
+			switch {
+			case col == 5:
+				g.Set(row, col, math.NaN())
+			case col < 5:
+				g.Set(row, col, 10)
+			default:
+				g.Set(row, col, 20)
+			}
+		}
+	}
+	g.Set(2, 2, 100)
+	g.Set(2, 8, 50)
+
+	peaks := g.Peaks(1)
+	if len(peaks) != 2 {
+		t.Fatalf("got %d peaks, want 2: %+v", len(peaks), peaks)
+	}
+	for i, want := range []struct{ elevation, prominence float64 }{{100, 90}, {50, 30}} {
+		p := peaks[i]
+		if !p.Highest || p.Elevation != want.elevation || p.Prominence != want.prominence {
+			t.Errorf("peak %d = %+v, want a highest peak of %g m with %g m prominence", i, p, want.elevation, want.prominence)
+		}
+	}
+}
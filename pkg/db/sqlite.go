@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"elevation"
 	"fmt"
+	"math"
 
 	_ "modernc.org/sqlite"
 )
@@ -26,6 +27,20 @@ type ElevationDB interface {
 	ReadFourNeighbors(ctx context.Context, lat float64, lng float64, spacing elevation.Spacing) ([4]elevation.HGTRecord, error)
 	// return the sixteen closest records to the passed lat,lng
 	ReadSixteenNeighbors(ctx context.Context, lat float64, lng float64, spacing elevation.Spacing) ([16]elevation.HGTRecord, error)
+	// return all records inside of the bounding box
+	ReadBoundingBox(ctx context.Context, bbox elevation.BoundingBox) ([]elevation.HGTRecord, error)
+	// return the records inside of the bounding box that lie on multiples of step * spacing
+	//
+	// spacing is the spacing of the records. for reading zoomed out views without reading every record
+	ReadBoundingBoxStep(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, step int) ([]elevation.HGTRecord, error)
+	// record the product and spacing of loaded tiles
+	//
+	// replaces tiles that were already recorded
+	CreateTiles(ctx context.Context, tiles []elevation.DEMTile) error
+	// return all recorded tiles
+	//
+	// dbs loaded before tiles were recorded have none
+	ReadTiles(ctx context.Context) ([]elevation.DEMTile, error)
 }
 
 func setupDB(db *sql.DB) error {
@@ -86,6 +101,16 @@ create table if not exists srtm (
 	primary key (latitude, longitude)
 ) without rowid;`
 
+// product and spacing of every loaded 1° tile
+const tilesSchema string = `
+create table if not exists tiles (
+	latitude integer,
+	longitude integer,
+	product text,
+	spacing real,
+	primary key (latitude, longitude)
+) without rowid;`
+
 // use readOnly when serving the data as nothing should be written to the db
 //
 // will create a sqlite db with PRAGMA journal_mode = WAL
@@ -102,6 +127,11 @@ func NewElevationDB(path string, readOnly bool) (ElevationDB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error failed to execute schema: %v", err)
 	}
+	_, err = db.Exec(tilesSchema)
+	if err != nil && !readOnly {
+		// older dbs opened read only have no tiles table, ReadTiles returns none for them
+		return nil, fmt.Errorf("error failed to execute schema: %v", err)
+	}
 	return &ElevationSQLiteDB{db}, nil
 }
 
@@ -253,3 +283,177 @@ order by latitude, longitude;`
 	}
 	return records, nil
 }
+
+func (db *ElevationSQLiteDB) ReadBoundingBox(ctx context.Context, bbox elevation.BoundingBox) ([]elevation.HGTRecord, error) {
+	q := `
+select latitude, longitude, elevation
+from srtm
+where latitude >= ?
+and latitude <= ?
+and longitude >= ?
+and longitude <= ?
+order by latitude, longitude;`
+
+	// small buffer so points sitting exactly on the edge are not lost to rounding
+	const eps = 1e-9
+	rows, err := db.QueryContext(ctx, q, bbox.MinLatitude-eps, bbox.MaxLatitude+eps, bbox.MinLongitude-eps, bbox.MaxLongitude+eps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []elevation.HGTRecord{}
+	for rows.Next() {
+		var latitude float64
+		var longitude float64
+		var elev float64
+		err := rows.Scan(
+			&latitude,
+			&longitude,
+			&elev,
+		)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, elevation.HGTRecord{Latitude: latitude, Longitude: longitude, Elevation: elev})
+	}
+	return records, rows.Err()
+}
+
+// steps up to this scan every record along a row of the lattice, larger steps look up each record on its own
+//
+// a scanned record costs about 1µs and a lookup about 30µs
+const maxScanStep = 32
+
+func (db *ElevationSQLiteDB) ReadBoundingBoxStep(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, step int) ([]elevation.HGTRecord, error) {
+	if step <= 1 {
+		return db.ReadBoundingBox(ctx, bbox)
+	}
+	s := float64(spacing) * float64(step)
+	// records are off the lattice by float error at most
+	tol := float64(spacing) / 4
+
+	// latitudes are compared exactly so the primary key is used for the longitude as well, a range on the
+	// latitude would scan the whole row. the same latitude can differ in the last bits between tiles
+	latStmt, err := db.PrepareContext(ctx, "select latitude from srtm where latitude > ? and latitude <= ? order by latitude limit 1;")
+	if err != nil {
+		return nil, err
+	}
+	defer latStmt.Close()
+	rowStmt, err := db.PrepareContext(ctx, `
+select longitude, elevation
+from srtm
+where latitude = ?
+and longitude >= ?
+and longitude <= ?
+and abs(longitude / ? - round(longitude / ?)) < ?
+order by longitude;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rowStmt.Close()
+	pointStmt, err := db.PrepareContext(ctx, "select longitude, elevation from srtm where latitude = ? and longitude >= ? and longitude <= ? limit 1;")
+	if err != nil {
+		return nil, err
+	}
+	defer pointStmt.Close()
+
+	records := []elevation.HGTRecord{}
+	west, east := math.Ceil(bbox.MinLongitude/s-1e-9), math.Floor(bbox.MaxLongitude/s+1e-9)
+	for k := math.Floor(bbox.MaxLatitude/s + 1e-9); k*s >= bbox.MinLatitude-1e-9; k-- {
+		lo, hi := k*s-tol, k*s+tol
+		for {
+			var latitude float64
+			err := latStmt.QueryRowContext(ctx, lo, hi).Scan(&latitude)
+			if err == sql.ErrNoRows {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			lo = latitude
+
+			if step <= maxScanStep {
+				rows, err := rowStmt.QueryContext(ctx, latitude, west*s-tol, east*s+tol, s, s, tol/s)
+				if err != nil {
+					return nil, err
+				}
+				for rows.Next() {
+					record := elevation.HGTRecord{Latitude: latitude}
+					if err := rows.Scan(&record.Longitude, &record.Elevation); err != nil {
+						rows.Close()
+						return nil, err
+					}
+					records = append(records, record)
+				}
+				rows.Close()
+				if err := rows.Err(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			for j := west; j <= east; j++ {
+				record := elevation.HGTRecord{Latitude: latitude}
+				err := pointStmt.QueryRowContext(ctx, latitude, j*s-tol, j*s+tol).Scan(&record.Longitude, &record.Elevation)
+				if err == sql.ErrNoRows {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+func (db *ElevationSQLiteDB) CreateTiles(ctx context.Context, tiles []elevation.DEMTile) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, "insert or replace into tiles (latitude, longitude, product, spacing) values (?,?,?,?);")
+	if err != nil {
+		return err
+	}
+	for _, tile := range tiles {
+		_, err := stmt.ExecContext(ctx, tile.Latitude, tile.Longitude, string(tile.Product), float64(tile.Spacing))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (db *ElevationSQLiteDB) ReadTiles(ctx context.Context) ([]elevation.DEMTile, error) {
+	var exists int
+	err := db.QueryRowContext(ctx, "select count(*) from sqlite_master where type = 'table' and name = 'tiles';").Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return []elevation.DEMTile{}, nil
+	}
+
+	rows, err := db.QueryContext(ctx, "select latitude, longitude, product, spacing from tiles;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiles := []elevation.DEMTile{}
+	for rows.Next() {
+		var tile elevation.DEMTile
+		var product string
+		var spacing float64
+		if err := rows.Scan(&tile.Latitude, &tile.Longitude, &product, &spacing); err != nil {
+			return nil, err
+		}
+		tile.Product = elevation.Product(product)
+		tile.Spacing = elevation.Spacing(spacing)
+		tiles = append(tiles, tile)
+	}
+	return tiles, rows.Err()
+}
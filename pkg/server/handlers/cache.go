@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"container/list"
+	"sync"
+)
+
+// a fixed size least recently used cache of rendered responses
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(cacheEntry).data, true
+}
+
+func (c *cache) Add(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value = cacheEntry{key, data}
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(cacheEntry{key, data})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).key)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"elevation/pkg/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// body of a /climbs request
+//
+// only path is required
+type climbsRequest struct {
+	Path          []elevation.Coordinate `json:"path"`
+	Interval      float64                `json:"interval"`
+	Interpolation string                 `json:"interpolation"`
+	MinGain       *float64               `json:"min_gain"`
+	MinLength     *float64               `json:"min_length"`
+	MinGrade      *float64               `json:"min_grade"`
+	MaxDescent    *float64               `json:"max_descent"`
+}
+
+// default distance (m) between sampled points along a route
+const defaultInterval = 30.0
+
+// expects a POST with a json body of the form:
+//
+//	{"path": [{"latitude": 0, "longitude": 0}, ...], "interval": 30, "interpolation": "bilinear"}
+//
+// the climb thresholds (min_gain, min_length, min_grade, max_descent) can optionally be overridden
+func (h *ElevationHandler) ClimbsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req climbsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		interpolationMethod := service.InterpolationMethod(req.Interpolation)
+		if interpolationMethod == "" {
+			interpolationMethod = service.Bilinear
+		}
+		if req.Interval == 0 {
+			req.Interval = defaultInterval
+		}
+		opts := elevation.DefaultClimbOptions
+		if req.MinGain != nil {
+			opts.MinGain = *req.MinGain
+		}
+		if req.MinLength != nil {
+			opts.MinLength = *req.MinLength
+		}
+		if req.MinGrade != nil {
+			opts.MinGrade = *req.MinGrade
+		}
+		if req.MaxDescent != nil {
+			opts.MaxDescent = *req.MaxDescent
+		}
+		if len(req.Path) < 2 {
+			http.Error(w, "path must contain at least 2 points", http.StatusBadRequest)
+			return
+		}
+
+		climbs, err := h.s.GetClimbs(context.Background(), req.Path, req.Interval, 0, interpolationMethod, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get climbs: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(climbs)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
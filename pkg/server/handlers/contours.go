@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - bbox (minLat,minLng,maxLat,maxLng)
+// - interval (default 10)
+// - index (default 50, 0 to disable)
+//
+// responds with a GeoJSON FeatureCollection of LineStrings
+func (h *ElevationHandler) ContoursHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		bbox, err := elevation.ParseBoundingBox(params.Get("bbox"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		interval, err := floatParam(params, "interval", 10)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		index, err := floatParam(params, "index", 50)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		contours, err := h.s.GetContours(context.Background(), bbox, 0, interval, index)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get contours: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		err = json.NewEncoder(w).Encode(elevation.ContoursToGeoJSON(contours))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
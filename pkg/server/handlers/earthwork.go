@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// body of a /cutfill request
+type cutFillRequest struct {
+	// a GeoJSON Polygon, MultiPolygon, Feature or FeatureCollection
+	Polygon json.RawMessage `json:"polygon"`
+	// elevation of a horizontal reference plane. a best fit plane is used if it is not set
+	Elevation *float64 `json:"elevation"`
+}
+
+// expects a POST with a json body of the form:
+//
+//	{"polygon": {"type": "Polygon", "coordinates": [...]}, "elevation": 250}
+//
+// responds with the cut, fill and net volumes in cubic meters
+func (h *ElevationHandler) CutFillHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req cutFillRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		polygons, err := elevation.ParsePolygons(req.Polygon)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse polygon: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		cf, err := h.s.GetCutFill(context.Background(), polygons, req.Elevation, 0)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get cut and fill: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cf); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -11,11 +11,23 @@ import (
 )
 
 type ElevationHandler struct {
-	s *service.ElevationService
+	s     *service.ElevationService
+	tiles *cache
+	// color ramps available to relief tiles by name
+	ramps map[string]elevation.ColorRamp
 }
 
 func NewElevationHandler(s *service.ElevationService) *ElevationHandler {
-	return &ElevationHandler{s: s}
+	ramps := map[string]elevation.ColorRamp{}
+	for name, ramp := range elevation.ColorRamps {
+		ramps[name] = ramp
+	}
+	return &ElevationHandler{s: s, tiles: newCache(tileCacheSize), ramps: ramps}
+}
+
+// make ramp available to relief tiles as name, replacing any existing ramp with that name
+func (h *ElevationHandler) AddColorRamp(name string, ramp elevation.ColorRamp) {
+	h.ramps[name] = ramp
 }
 
 // checks for the following query params:
@@ -45,7 +57,7 @@ func (h *ElevationHandler) ElevationHandler(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		record, err := h.s.GetPointElevation(context.Background(), lat, lng, elevation.SRTM1, interpolationMethod)
+		record, err := h.s.GetPointElevation(context.Background(), lat, lng, 0, interpolationMethod)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("unable to get elevation: %s", err.Error()), http.StatusInternalServerError)
 			return
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - bbox (minLat,minLng,maxLat,maxLng)
+// - level in meters
+// - seed (lat,lng) (optional) to only flood the area connected to it
+// - format (can be geojson, json, png, tiff) (default geojson)
+//
+// json only contains the area and volume statistics. tiff is the mask with 1 for flooded cells
+func (h *ElevationHandler) FloodHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		bbox, err := elevation.ParseBoundingBox(params.Get("bbox"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if params.Get("level") == "" {
+			http.Error(w, "level is required", http.StatusBadRequest)
+			return
+		}
+		level, err := floatParam(params, "level", 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var seed *elevation.Coordinate
+		if params.Get("seed") != "" {
+			c, err := elevation.ParseCoordinate(params.Get("seed"))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to parse seed: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+			seed = &c
+		}
+		format := params.Get("format")
+		if format == "" {
+			format = "geojson"
+		}
+
+		flood, err := h.s.GetFlood(context.Background(), bbox, level, seed, 0)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get flood: %s", err.Error()), errorStatus(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		switch format {
+		case "geojson":
+			w.Header().Set("Content-Type", "application/geo+json")
+			err = elevation.WriteGeoJSON(&buf, elevation.FloodToGeoJSON(flood))
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(&buf).Encode(flood)
+		case "png":
+			w.Header().Set("Content-Type", "image/png")
+			err = elevation.FloodToPNG(&buf, flood)
+		case "tiff":
+			w.Header().Set("Content-Type", "image/tiff")
+			err = elevation.GridToGeoTIFF(&buf, flood.Mask)
+		default:
+			http.Error(w, fmt.Sprintf("invalid format: %s", format), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode %s: %s", format, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - from (lat,lng of the first antenna)
+// - to (lat,lng of the second antenna)
+// - from_height (default 10)
+// - to_height (default 10)
+// - frequency in MHz (required)
+// - k (default 1.333)
+// - format (can be json, svg) (default json)
+func (h *ElevationHandler) FresnelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		from, err := elevation.ParseCoordinate(params.Get("from"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse from: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		to, err := elevation.ParseCoordinate(params.Get("to"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse to: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		opts := elevation.FresnelOptions{}
+		if opts.FromHeight, err = floatParam(params, "from_height", 10); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.ToHeight, err = floatParam(params, "to_height", 10); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mhz, err := floatParam(params, "frequency", 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.Frequency = mhz * 1e6
+		if opts.KFactor, err = floatParam(params, "k", elevation.StandardKFactor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := params.Get("format")
+		if format == "" {
+			format = "json"
+		}
+
+		analysis, err := h.s.GetFresnel(context.Background(), from, to, 0, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get fresnel zone: %s", err.Error()), errorStatus(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		switch format {
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(&buf).Encode(analysis)
+		case "svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			err = elevation.FresnelToSVG(&buf, analysis)
+		default:
+			http.Error(w, fmt.Sprintf("invalid format: %s", format), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode %s: %s", format, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - bbox (minLat,minLng,maxLat,maxLng)
+// - azimuth (default 315)
+// - altitude (default 45)
+// - z (default 1)
+// - multidirectional (default false)
+//
+// responds with a grayscale png
+func (h *ElevationHandler) HillshadeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		bbox, err := elevation.ParseBoundingBox(params.Get("bbox"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := elevation.DefaultHillshadeOptions
+		if opts.Azimuth, err = floatParam(params, "azimuth", opts.Azimuth); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Altitude, err = floatParam(params, "altitude", opts.Altitude); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.ZFactor, err = floatParam(params, "z", opts.ZFactor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.MultiDirectional, err = boolParam(params, "multidirectional", opts.MultiDirectional); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		g, err := h.s.GetHillshade(context.Background(), bbox, 0, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get hillshade: %s", err.Error()), errorStatus(err))
+			return
+		}
+		var buf bytes.Buffer
+		if err := elevation.GridToGrayPNG(&buf, g); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode png: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - observer (lat,lng)
+// - eye_height (default 1.7)
+// - radius (default 40000)
+// - step in degrees (default 0.1)
+// - curvature (default true)
+// - refraction (default 0.13)
+// - format (can be json, svg, png) (default json)
+func (h *ElevationHandler) HorizonHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		observer, err := elevation.ParseCoordinate(params.Get("observer"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse observer: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		opts := elevation.DefaultHorizonOptions
+		if opts.EyeHeight, err = floatParam(params, "eye_height", opts.EyeHeight); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Radius, err = floatParam(params, "radius", opts.Radius); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Step, err = floatParam(params, "step", opts.Step); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Curvature, err = boolParam(params, "curvature", opts.Curvature); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Refraction, err = floatParam(params, "refraction", opts.Refraction); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := params.Get("format")
+		if format == "" {
+			format = "json"
+		}
+
+		horizon, err := h.s.GetHorizon(context.Background(), observer, 0, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get horizon: %s", err.Error()), errorStatus(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		switch format {
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(&buf).Encode(horizon)
+		case "svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			err = elevation.HorizonToSVG(&buf, horizon)
+		case "png":
+			w.Header().Set("Content-Type", "image/png")
+			err = elevation.HorizonToPNG(&buf, horizon)
+		default:
+			http.Error(w, fmt.Sprintf("invalid format: %s", format), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode %s: %s", format, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
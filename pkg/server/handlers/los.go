@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - from (lat,lng of the observer)
+// - to (lat,lng of the target)
+// - observer_height (default 1.7)
+// - target_height (default 0)
+// - curvature (default true)
+// - refraction (default 0.13)
+func (h *ElevationHandler) LineOfSightHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		from, err := elevation.ParseCoordinate(params.Get("from"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse from: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		to, err := elevation.ParseCoordinate(params.Get("to"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse to: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		opts := elevation.LineOfSightOptions{}
+		if opts.ObserverHeight, err = floatParam(params, "observer_height", 1.7); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.TargetHeight, err = floatParam(params, "target_height", 0); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Curvature, err = boolParam(params, "curvature", true); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Refraction, err = floatParam(params, "refraction", elevation.StandardRefraction); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		los, err := h.s.GetLineOfSight(context.Background(), from, to, 0, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get line of sight: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(los)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"elevation/pkg/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// parse the float query param name, returning def if it is not set
+func floatParam(params url.Values, name string, def float64) (float64, error) {
+	v := params.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse %s: %s", name, err.Error())
+	}
+	return f, nil
+}
+
+// parse the int query param name, returning def if it is not set
+func intParam(params url.Values, name string, def int) (int, error) {
+	v := params.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse %s: %s", name, err.Error())
+	}
+	return i, nil
+}
+
+// parse the bool query param name, returning def if it is not set
+func boolParam(params url.Values, name string, def bool) (bool, error) {
+	v := params.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse %s: %s", name, err.Error())
+	}
+	return b, nil
+}
+
+// parse the RFC 3339 time query param name, returning def if it is not set
+func timeParam(params url.Values, name string, def time.Time) (time.Time, error) {
+	v := params.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse %s: %s", name, err.Error())
+	}
+	return t, nil
+}
+
+// 400 for errors caused by the request parameters, 500 for everything else
+func errorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidParameter) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
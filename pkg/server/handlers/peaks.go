@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - bbox (minLat,minLng,maxLat,maxLng)
+// - min_prominence (default 30)
+//
+// responds with a GeoJSON FeatureCollection of Points ranked by prominence
+func (h *ElevationHandler) PeaksHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		bbox, err := elevation.ParseBoundingBox(params.Get("bbox"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		minProminence, err := floatParam(params, "min_prominence", 30)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		peaks, err := h.s.GetPeaks(context.Background(), bbox, 0, minProminence)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get peaks: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		err = json.NewEncoder(w).Encode(elevation.PeaksToGeoJSON(peaks))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
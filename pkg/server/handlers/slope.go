@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// checks for the following query params:
+// - latitude
+// - longitude
+// - algorithm (can be horn, zevenbergen-thorne) (default horn)
+func (h *ElevationHandler) SlopeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		alg := elevation.SlopeAlgorithm(params.Get("algorithm"))
+		if alg == "" {
+			alg = elevation.Horn
+		}
+		latStr := r.PathValue("latitude")
+		lngStr := r.PathValue("longitude")
+
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse latitude: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		lng, err := strconv.ParseFloat(lngStr, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse longitude: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		slope, err := h.s.GetSlope(context.Background(), lat, lng, 0, alg)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get slope: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(slope)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
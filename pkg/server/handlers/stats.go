@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// body of a /stats request
+//
+// one of polygon or bbox is required
+type statsRequest struct {
+	// a GeoJSON Polygon, MultiPolygon, Feature or FeatureCollection
+	Polygon json.RawMessage `json:"polygon"`
+	// minLat,minLng,maxLat,maxLng
+	BBox        string    `json:"bbox"`
+	Percentiles []float64 `json:"percentiles"`
+	// number of hypsometric histogram bands
+	Bins int `json:"bins"`
+}
+
+// default number of hypsometric histogram bands
+const defaultBins = 20
+
+// expects a POST with a json body of the form:
+//
+//	{"polygon": {"type": "Polygon", "coordinates": [...]}, "percentiles": [5, 95], "bins": 20}
+//
+// responds with statistics of the elevations inside of the polygon
+func (h *ElevationHandler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req statsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		var polygons [][][]elevation.Position
+		switch {
+		case len(req.Polygon) > 0:
+			var err error
+			if polygons, err = elevation.ParsePolygons(req.Polygon); err != nil {
+				http.Error(w, fmt.Sprintf("unable to parse polygon: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+		case req.BBox != "":
+			bbox, err := elevation.ParseBoundingBox(req.BBox)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			polygons = [][][]elevation.Position{{{
+				{bbox.MinLongitude, bbox.MinLatitude},
+				{bbox.MaxLongitude, bbox.MinLatitude},
+				{bbox.MaxLongitude, bbox.MaxLatitude},
+				{bbox.MinLongitude, bbox.MaxLatitude},
+				{bbox.MinLongitude, bbox.MinLatitude},
+			}}}
+		default:
+			http.Error(w, "polygon or bbox is required", http.StatusBadRequest)
+			return
+		}
+		if req.Percentiles == nil {
+			req.Percentiles = elevation.DefaultPercentiles
+		}
+		if req.Bins == 0 {
+			req.Bins = defaultBins
+		}
+
+		stats, err := h.s.GetZonalStatistics(context.Background(), polygons, 0, req.Percentiles, req.Bins)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get statistics: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - bbox (minLat,minLng,maxLat,maxLng)
+// - threshold (default 1000) number of cells that must drain through a cell for it to be a stream
+//
+// responds with a GeoJSON FeatureCollection of LineStrings
+func (h *ElevationHandler) StreamsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		bbox, err := elevation.ParseBoundingBox(params.Get("bbox"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		threshold, err := floatParam(params, "threshold", 1000)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		streams, err := h.s.GetStreams(context.Background(), bbox, 0, threshold)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get streams: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		err = json.NewEncoder(w).Encode(elevation.StreamsToGeoJSON(streams))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
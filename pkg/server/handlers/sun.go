@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// checks for the following query params:
+// - point (lat,lng)
+// - time in RFC 3339 (default now)
+// - date (YYYY-MM-DD). when set, responds with the sun hours of that day instead
+// - interval in minutes between sun hour samples (default 5)
+// - eye_height (default 1.7)
+// - radius to search for the horizon (default 40000)
+//
+// responds with json
+func (h *ElevationHandler) SunHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		point, err := elevation.ParseCoordinate(params.Get("point"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse point: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		opts := elevation.DefaultHorizonOptions
+		if opts.EyeHeight, err = floatParam(params, "eye_height", opts.EyeHeight); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Radius, err = floatParam(params, "radius", opts.Radius); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var resp any
+		if date := params.Get("date"); date != "" {
+			day, err := time.Parse(time.DateOnly, date)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to parse date: %s", err.Error()), http.StatusBadRequest)
+				return
+			}
+			interval, err := floatParam(params, "interval", 5)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err = h.s.GetSunHours(context.Background(), point, day, time.Duration(interval*float64(time.Minute)), 0, opts)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to get sun hours: %s", err.Error()), errorStatus(err))
+				return
+			}
+		} else {
+			t, err := timeParam(params, "time", time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err = h.s.GetSunExposure(context.Background(), point, t, 0, opts)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to get sun exposure: %s", err.Error()), errorStatus(err))
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// checks for the following query params:
+// - bbox (minLat,minLng,maxLat,maxLng)
+// - time in RFC 3339 (default now)
+// - radius in meters that shadows are cast from outside of the bbox (default 5000)
+// - format (can be png, tiff, geojson) (default png)
+func (h *ElevationHandler) ShadowHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		bbox, err := elevation.ParseBoundingBox(params.Get("bbox"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t, err := timeParam(params, "time", time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		radius, err := floatParam(params, "radius", 5000)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := params.Get("format")
+		if format == "" {
+			format = "png"
+		}
+
+		shadow, err := h.s.GetShadow(context.Background(), bbox, t, radius, 0)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get shadow: %s", err.Error()), errorStatus(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		switch format {
+		case "png":
+			w.Header().Set("Content-Type", "image/png")
+			err = elevation.ShadowToPNG(&buf, shadow)
+		case "tiff":
+			w.Header().Set("Content-Type", "image/tiff")
+			err = elevation.GridToGeoTIFF(&buf, shadow)
+		case "geojson":
+			w.Header().Set("Content-Type", "application/geo+json")
+			err = elevation.WriteGeoJSON(&buf, elevation.ShadowToGeoJSON(shadow))
+		default:
+			http.Error(w, fmt.Sprintf("invalid format: %s", format), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode %s: %s", format, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
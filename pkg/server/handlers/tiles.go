@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"elevation/pkg/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// number of rendered tiles kept in memory
+const tileCacheSize = 2048
+
+// parse the {z}/{x}/{y}.png (or .terrain) path values
+func tilePath(r *http.Request) (int, int, int, error) {
+	z, err := strconv.Atoi(r.PathValue("z"))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unable to parse z: %s", err.Error())
+	}
+	x, err := strconv.Atoi(r.PathValue("x"))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unable to parse x: %s", err.Error())
+	}
+	y, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(r.PathValue("y"), ".png"), ".terrain"))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unable to parse y: %s", err.Error())
+	}
+	return z, x, y, nil
+}
+
+// parse a web mercator tile from the path
+func tileParam(r *http.Request) (elevation.Tile, error) {
+	z, x, y, err := tilePath(r)
+	if err != nil {
+		return elevation.Tile{}, err
+	}
+	t := elevation.Tile{Z: z, X: x, Y: y}
+	return t, t.Validate()
+}
+
+// parse the hillshade query params shared by hillshade and relief tiles
+func hillshadeParams(params url.Values) (elevation.HillshadeOptions, error) {
+	opts := elevation.DefaultHillshadeOptions
+	var err error
+	if opts.Azimuth, err = floatParam(params, "azimuth", opts.Azimuth); err != nil {
+		return opts, err
+	}
+	if opts.Altitude, err = floatParam(params, "altitude", opts.Altitude); err != nil {
+		return opts, err
+	}
+	if opts.ZFactor, err = floatParam(params, "z", opts.ZFactor); err != nil {
+		return opts, err
+	}
+	if opts.MultiDirectional, err = boolParam(params, "multidirectional", opts.MultiDirectional); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// the hillshade options in a canonical query string form, for cache keys
+func hillshadeKey(opts elevation.HillshadeOptions) string {
+	return fmt.Sprintf("azimuth=%g&altitude=%g&z=%g&multidirectional=%t", opts.Azimuth, opts.Altitude, opts.ZFactor, opts.MultiDirectional)
+}
+
+// serves /tiles/{kind}/{z}/{x}/{y}.png web mercator tiles
+//
+// kind can be:
+// - terrain-rgb or terrarium elevation tiles
+// - hillshade, with the azimuth, altitude, z and multidirectional query params
+// - relief, with the ramp (default hypsometric) and shade (default false) query params. when shade
+// is set the hillshade params are also used
+//
+// tiles are cached in memory
+func (h *ElevationHandler) TileHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tile, err := tileParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params := r.URL.Query()
+		kind := r.PathValue("kind")
+
+		// the cache key only has the params the kind uses, so other query params do not split the cache
+		key := kind + "/" + tile.String()
+		var render func() ([]byte, error)
+		switch kind {
+		case string(elevation.TerrainRGB), string(elevation.Terrarium):
+			render = func() ([]byte, error) {
+				return h.s.GetElevationTile(context.Background(), tile, elevation.TileEncoding(kind), 0)
+			}
+		case "hillshade":
+			opts, err := hillshadeParams(params)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			key += "?" + hillshadeKey(opts)
+			render = func() ([]byte, error) {
+				return h.s.GetHillshadeTile(context.Background(), tile, opts, 0)
+			}
+		case "relief":
+			name := params.Get("ramp")
+			if name == "" {
+				name = "hypsometric"
+			}
+			ramp, ok := h.ramps[name]
+			if !ok {
+				http.Error(w, fmt.Sprintf("invalid color ramp: %s", name), http.StatusBadRequest)
+				return
+			}
+			shade, err := boolParam(params, "shade", false)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			key += "?ramp=" + url.QueryEscape(name)
+			var opts *elevation.HillshadeOptions
+			if shade {
+				o, err := hillshadeParams(params)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				opts = &o
+				key += "&" + hillshadeKey(o)
+			}
+			render = func() ([]byte, error) {
+				return h.s.GetReliefTile(context.Background(), tile, ramp, opts, 0)
+			}
+		default:
+			http.Error(w, fmt.Sprintf("invalid tile type: %s", kind), http.StatusNotFound)
+			return
+		}
+
+		data, ok := h.tiles.Get(key)
+		if !ok {
+			data, err = render()
+			if errors.Is(err, service.ErrEmptyTile) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to render tile: %s", err.Error()), errorStatus(err))
+				return
+			}
+			h.tiles.Add(key, data)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write(data)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serves the layer.json of the quantized mesh terrain
+func (h *ElevationHandler) QuantizedMeshLayerHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		// deep enough for the finest loaded tiles
+		world := elevation.BoundingBox{MinLatitude: -90, MinLongitude: -180, MaxLatitude: 90, MaxLongitude: 180}
+		spacing, err := h.s.GetSpacing(context.Background(), world)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to read tiles: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+		layer := elevation.QuantizedMeshLayer(elevation.QuantizedMeshMaxZoom(spacing), "{z}/{x}/{y}.terrain?v={version}", []string{"octvertexnormals"})
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(layer)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serves /tiles/quantized-mesh/{z}/{x}/{y}.terrain tiles for cesium
+//
+// tiles are in the geographic tms scheme. vertex normals are included when the
+// Accept header asks for the octvertexnormals extension (or with ?normals=true)
+func (h *ElevationHandler) QuantizedMeshHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		z, x, y, err := tilePath(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tile := elevation.GeographicTile{Z: z, X: x, Y: y}
+		if err := tile.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		normals, err := boolParam(r.URL.Query(), "normals", false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		normals = normals || strings.Contains(r.Header.Get("Accept"), "octvertexnormals")
+		key := "quantized-mesh/" + tile.String() + "?normals=" + strconv.FormatBool(normals)
+
+		data, ok := h.tiles.Get(key)
+		if !ok {
+			data, err = h.s.GetQuantizedMeshTile(context.Background(), tile, normals, 0)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to render tile: %s", err.Error()), errorStatus(err))
+				return
+			}
+			h.tiles.Add(key, data)
+		}
+		contentType := "application/vnd.quantized-mesh"
+		if normals {
+			contentType += ";extensions=octvertexnormals"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		// the normals depend on the Accept header, so caches must not share tiles across it
+		w.Header().Set("Vary", "Accept")
+		w.Write(data)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - observer (lat,lng)
+// - radius (default 5000)
+// - observer_height (default 1.7)
+// - target_height (default 0)
+// - curvature (default true)
+// - refraction (default 0.13)
+// - format (can be png, tiff, geojson) (default png)
+func (h *ElevationHandler) ViewshedHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		observer, err := elevation.ParseCoordinate(params.Get("observer"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse observer: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		opts := elevation.ViewshedOptions{}
+		if opts.Radius, err = floatParam(params, "radius", 5000); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.ObserverHeight, err = floatParam(params, "observer_height", 1.7); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.TargetHeight, err = floatParam(params, "target_height", 0); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Curvature, err = boolParam(params, "curvature", true); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if opts.Refraction, err = floatParam(params, "refraction", elevation.StandardRefraction); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := params.Get("format")
+		if format == "" {
+			format = "png"
+		}
+
+		viewshed, err := h.s.GetViewshed(context.Background(), observer, 0, opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get viewshed: %s", err.Error()), errorStatus(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		switch format {
+		case "png":
+			w.Header().Set("Content-Type", "image/png")
+			err = elevation.ViewshedToPNG(&buf, viewshed)
+		case "tiff":
+			w.Header().Set("Content-Type", "image/tiff")
+			err = elevation.GridToGeoTIFF(&buf, viewshed)
+		case "geojson":
+			w.Header().Set("Content-Type", "application/geo+json")
+			err = elevation.WriteGeoJSON(&buf, elevation.ViewshedToGeoJSON(viewshed))
+		default:
+			http.Error(w, fmt.Sprintf("invalid format: %s", format), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode %s: %s", format, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"elevation"
+	"elevation/pkg/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// checks for the following query params:
+// - pour (lat,lng)
+// - bbox (minLat,minLng,maxLat,maxLng) (default 0.1 degrees around the pour point)
+// - snap (default 5) cells to search for the highest accumulation around the pour point
+//
+// responds with a GeoJSON FeatureCollection containing the catchment
+func (h *ElevationHandler) WatershedHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+
+		pour, err := elevation.ParseCoordinate(params.Get("pour"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to parse pour: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		bbox := elevation.PathBoundingBox([]elevation.Coordinate{pour}, service.DefaultWatershedExtent)
+		if params.Get("bbox") != "" {
+			if bbox, err = elevation.ParseBoundingBox(params.Get("bbox")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		snap, err := intParam(params, "snap", 5)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ws, err := h.s.GetWatershed(context.Background(), pour, bbox, 0, snap)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to get watershed: %s", err.Error()), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		err = json.NewEncoder(w).Encode(elevation.WatershedToGeoJSON(ws))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
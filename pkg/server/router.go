@@ -1,6 +1,7 @@
 package server
 
 import (
+	"elevation"
 	"elevation/pkg/server/handlers"
 	"elevation/pkg/service"
 	"fmt"
@@ -11,11 +12,34 @@ func router(handler *handlers.ElevationHandler) http.Handler {
 	// /api routes
 	api := http.NewServeMux()
 	api.HandleFunc("/elevation/{latitude}/{longitude}", handler.ElevationHandler)
+	api.HandleFunc("/climbs", handler.ClimbsHandler)
+	api.HandleFunc("/slope/{latitude}/{longitude}", handler.SlopeHandler)
+	api.HandleFunc("/hillshade", handler.HillshadeHandler)
+	api.HandleFunc("/contours", handler.ContoursHandler)
+	api.HandleFunc("/los", handler.LineOfSightHandler)
+	api.HandleFunc("/viewshed", handler.ViewshedHandler)
+	api.HandleFunc("/fresnel", handler.FresnelHandler)
+	api.HandleFunc("/streams", handler.StreamsHandler)
+	api.HandleFunc("/watershed", handler.WatershedHandler)
+	api.HandleFunc("/peaks", handler.PeaksHandler)
+	api.HandleFunc("/horizon", handler.HorizonHandler)
+	api.HandleFunc("/sun", handler.SunHandler)
+	api.HandleFunc("/shadow", handler.ShadowHandler)
+	api.HandleFunc("/flood", handler.FloodHandler)
+	api.HandleFunc("/cutfill", handler.CutFillHandler)
+	api.HandleFunc("/stats", handler.StatsHandler)
+	api.HandleFunc("/tiles/{kind}/{z}/{x}/{y}", handler.TileHandler)
+	api.HandleFunc("/tiles/quantized-mesh/layer.json", handler.QuantizedMeshLayerHandler)
+	api.HandleFunc("/tiles/quantized-mesh/{z}/{x}/{y}", handler.QuantizedMeshHandler)
 	return api
 }
 
-func Serve(address string, port int, s *service.ElevationService) error {
+// ramps are added to the built in color ramps for relief tiles
+func Serve(address string, port int, s *service.ElevationService, ramps map[string]elevation.ColorRamp) error {
 	h := handlers.NewElevationHandler(s)
+	for name, ramp := range ramps {
+		h.AddColorRamp(name, ramp)
+	}
 	r := router(h)
 
 	err := http.ListenAndServe(fmt.Sprintf("%s:%d", address, port), r)
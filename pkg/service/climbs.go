@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// sample the path and return the climbs along it
+func (s *ElevationService) GetClimbs(ctx context.Context, path []elevation.Coordinate, interval float64, spacing elevation.Spacing, interpolationMethod InterpolationMethod, opts elevation.ClimbOptions) ([]elevation.Climb, error) {
+	profile, err := s.GetProfile(ctx, path, interval, spacing, interpolationMethod)
+	if err != nil {
+		return nil, err
+	}
+	return elevation.DetectClimbs(profile, opts), nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// the most contour levels a single request may trace
+const MaxContourLevels = 1000
+
+// contour lines every interval meters for the bounding box
+//
+// index contours are flagged every indexInterval meters (0 to disable)
+func (s *ElevationService) GetContours(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, interval float64, indexInterval float64) ([]elevation.Contour, error) {
+	if interval <= 0 {
+		return nil, invalidParameter("invalid interval: %f", interval)
+	}
+	if indexInterval < 0 {
+		return nil, invalidParameter("invalid index interval: %f", indexInterval)
+	}
+	// the grid is built across tile boundaries so lines are continuous over seams
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	// every level is a pass over the whole grid
+	if lo, hi := g.MinMax(); (hi-lo)/interval > MaxContourLevels {
+		return nil, invalidParameter("interval too small: %.0f levels between %g and %g (max %d)", (hi-lo)/interval, lo, hi, MaxContourLevels)
+	}
+	return g.Contours(interval, indexInterval), nil
+}
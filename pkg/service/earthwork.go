@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// cut and fill volumes inside of polygons
+//
+// the terrain is compared against a horizontal plane at level, or a best fit plane if level is nil
+func (s *ElevationService) GetCutFill(ctx context.Context, polygons [][][]elevation.Position, level *float64, spacing elevation.Spacing) (elevation.CutFill, error) {
+	bbox := elevation.PolygonsBoundingBox(polygons)
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return elevation.CutFill{}, err
+	}
+	inside := g.PolygonCells(polygons)
+	var plane elevation.Plane
+	if level != nil {
+		bbox := g.BoundingBox()
+		plane = elevation.Plane{
+			Origin:    elevation.Coordinate{Latitude: (bbox.MinLatitude + bbox.MaxLatitude) / 2, Longitude: (bbox.MinLongitude + bbox.MaxLongitude) / 2},
+			Elevation: *level,
+		}
+	} else if plane, err = g.FitPlane(inside); err != nil {
+		return elevation.CutFill{}, err
+	}
+	return g.CutFill(inside, plane)
+}
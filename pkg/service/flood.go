@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// area inside of bbox inundated by water at level
+//
+// if seed is set only the area connected to it is flooded
+func (s *ElevationService) GetFlood(ctx context.Context, bbox elevation.BoundingBox, level float64, seed *elevation.Coordinate, spacing elevation.Spacing) (elevation.Flood, error) {
+	if seed != nil && !bbox.Contains(seed.Latitude, seed.Longitude) {
+		return elevation.Flood{}, invalidParameter("seed must be inside of the bounding box")
+	}
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return elevation.Flood{}, err
+	}
+	// a seed on a void or above the water is a bad request, not a failure
+	if seed != nil {
+		row, col := g.Cell(seed.Latitude, seed.Longitude)
+		if !g.Valid(row, col) {
+			return elevation.Flood{}, invalidParameter("no data at seed %f, %f", seed.Latitude, seed.Longitude)
+		}
+		if v := g.At(row, col); v > level {
+			return elevation.Flood{}, invalidParameter("seed elevation %.1f is above the water level", v)
+		}
+	}
+	return g.Flood(level, seed)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"fmt"
+	"math"
+)
+
+// analyze the first Fresnel zone of the link between from and to
+func (s *ElevationService) GetFresnel(ctx context.Context, from elevation.Coordinate, to elevation.Coordinate, spacing elevation.Spacing, opts elevation.FresnelOptions) (elevation.FresnelAnalysis, error) {
+	if opts.FromHeight < 0 || opts.ToHeight < 0 {
+		return elevation.FresnelAnalysis{}, invalidParameter("heights must not be negative")
+	}
+	if opts.Frequency <= 0 {
+		return elevation.FresnelAnalysis{}, invalidParameter("invalid frequency: %f", opts.Frequency)
+	}
+	if opts.KFactor <= 0 {
+		return elevation.FresnelAnalysis{}, invalidParameter("invalid k-factor: %f", opts.KFactor)
+	}
+	if from == to {
+		return elevation.FresnelAnalysis{}, invalidParameter("from and to must be different")
+	}
+	profile, err := s.getGridProfile(ctx, []elevation.Coordinate{from, to}, spacing)
+	if err != nil {
+		return elevation.FresnelAnalysis{}, err
+	}
+	if math.IsNaN(profile[0].Elevation) || math.IsNaN(profile[len(profile)-1].Elevation) {
+		return elevation.FresnelAnalysis{}, fmt.Errorf("no data at from or to")
+	}
+	return elevation.ComputeFresnel(profile, opts), nil
+}
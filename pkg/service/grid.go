@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"math"
+)
+
+// the largest number of cells a single grid request may cover
+//
+// protects the server from requests that would read entire continents into memory
+const MaxGridCells = 25_000_000
+
+// read the bounding box into a grid
+//
+// a spacing of 0 reads it at the finest spacing of the loaded tiles, see GetSpacing
+func (s *ElevationService) GetGrid(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing) (*elevation.Grid, error) {
+	if err := bbox.Validate(); err != nil {
+		return nil, err
+	}
+	spacing, err := s.resolveSpacing(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	if n := elevation.GridCells(bbox, spacing); n > MaxGridCells {
+		return nil, invalidParameter("bounding box too large: %d cells (max %d)", n, MaxGridCells)
+	}
+	records, err := s.db.ReadBoundingBox(ctx, bbox)
+	if err != nil {
+		return nil, err
+	}
+	g := elevation.NewGridFromRecords(bbox, spacing, records)
+
+	// the records of tiles coarser than spacing only land on some of the cells, interpolate the rest
+	tiles, err := s.tilesIn(ctx, bbox)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tiles {
+		if t.Spacing <= spacing {
+			continue
+		}
+		tile := elevation.BoundingBox{
+			MinLatitude:  math.Max(float64(t.Latitude), bbox.MinLatitude),
+			MinLongitude: math.Max(float64(t.Longitude), bbox.MinLongitude),
+			MaxLatitude:  math.Min(float64(t.Latitude+1), bbox.MaxLatitude),
+			MaxLongitude: math.Min(float64(t.Longitude+1), bbox.MaxLongitude),
+		}
+		g.FillVoids(elevation.NewGridFromRecords(tile.Buffer(float64(t.Spacing)), t.Spacing, records), tile)
+	}
+	return g, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"image"
+)
+
+// the widest or tallest heightmap that will be rendered, in pixels
+const MaxHeightmapSize = 16384
+
+// the largest heightmap that will be rendered, in pixels
+const MaxHeightmapPixels = 8192 * 8192
+
+// 16 bit heightmap of the bounding box and the metadata to georeference it
+func (s *ElevationService) GetHeightmap(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, opts elevation.HeightmapOptions) (*image.Gray16, elevation.HeightmapMetadata, error) {
+	if err := bbox.Validate(); err != nil {
+		return nil, elevation.HeightmapMetadata{}, err
+	}
+	spacing, err := s.resolveSpacing(ctx, bbox, spacing)
+	if err != nil {
+		return nil, elevation.HeightmapMetadata{}, err
+	}
+	// a size of 0 is one pixel per cell, resolve it before checking the size
+	rows, cols := elevation.GridSize(bbox, spacing)
+	if opts.Width == 0 {
+		opts.Width = cols
+	}
+	if opts.Height == 0 {
+		opts.Height = rows
+	}
+	if opts.Width < 1 || opts.Height < 1 || opts.Width > MaxHeightmapSize || opts.Height > MaxHeightmapSize {
+		return nil, elevation.HeightmapMetadata{}, invalidParameter("invalid heightmap size: %dx%d (max %d per side)", opts.Width, opts.Height, MaxHeightmapSize)
+	}
+	if opts.Width*opts.Height > MaxHeightmapPixels {
+		return nil, elevation.HeightmapMetadata{}, invalidParameter("heightmap too large: %dx%d (max %d pixels)", opts.Width, opts.Height, MaxHeightmapPixels)
+	}
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return nil, elevation.HeightmapMetadata{}, err
+	}
+	return g.Heightmap(opts)
+}
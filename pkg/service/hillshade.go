@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// shaded relief for the bounding box
+func (s *ElevationService) GetHillshade(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, opts elevation.HillshadeOptions) (*elevation.Grid, error) {
+	if opts.Altitude < 0 || opts.Altitude > 90 {
+		return nil, invalidParameter("invalid altitude: %f", opts.Altitude)
+	}
+	spacing, err := s.resolveSpacing(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	// buffer by one cell so the edges of the box have neighbors
+	g, err := s.GetGrid(ctx, bbox.Buffer(float64(spacing)), spacing)
+	if err != nil {
+		return nil, err
+	}
+	return g.Hillshade(opts).Crop(bbox), nil
+}
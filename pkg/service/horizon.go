@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// the finest azimuth step (degrees) of a horizon, 36000 rays around the observer
+const MinHorizonStep = 0.01
+
+func validateHorizonOptions(opts elevation.HorizonOptions) error {
+	if opts.Radius <= 0 {
+		return invalidParameter("invalid radius: %f", opts.Radius)
+	}
+	if opts.Step < MinHorizonStep || opts.Step > 90 {
+		return invalidParameter("invalid step: %f (must be %g-90)", opts.Step, MinHorizonStep)
+	}
+	if opts.EyeHeight < 0 {
+		return invalidParameter("eye height must not be negative")
+	}
+	if opts.Refraction < 0 || opts.Refraction >= 1 {
+		return invalidParameter("invalid refraction coefficient: %f", opts.Refraction)
+	}
+	return nil
+}
+
+// read the terrain within the horizon radius of observer
+func (s *ElevationService) getHorizonGrid(ctx context.Context, observer elevation.Coordinate, spacing elevation.Spacing, opts elevation.HorizonOptions) (*elevation.Grid, error) {
+	if err := validateHorizonOptions(opts); err != nil {
+		return nil, err
+	}
+	return s.GetGrid(ctx, elevation.RadiusBoundingBox(observer.Latitude, observer.Longitude, opts.Radius), spacing)
+}
+
+// skyline seen from observer
+func (s *ElevationService) GetHorizon(ctx context.Context, observer elevation.Coordinate, spacing elevation.Spacing, opts elevation.HorizonOptions) (elevation.Horizon, error) {
+	g, err := s.getHorizonGrid(ctx, observer, spacing, opts)
+	if err != nil {
+		return elevation.Horizon{}, err
+	}
+	return g.Horizon(observer, opts)
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// filled dem, flow directions and flow accumulation for the bounding box
+//
+// flow is only routed inside of the bounding box, so cells near the edges may
+// be missing some of their upstream area
+func (s *ElevationService) GetHydrology(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing) (elevation.Hydrology, error) {
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return elevation.Hydrology{}, err
+	}
+	return g.Hydrology(), nil
+}
+
+// streams where at least threshold cells drain through
+func (s *ElevationService) GetStreams(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, threshold float64) ([]elevation.Stream, error) {
+	h, err := s.GetHydrology(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	return h.Streams(threshold), nil
+}
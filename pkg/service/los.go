@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"fmt"
+	"math"
+)
+
+// distance (m) covered by one cell of spacing along a meridian
+func spacingMeters(spacing elevation.Spacing) float64 {
+	return elevation.EarthRadius * float64(spacing) * math.Pi / 180
+}
+
+// sample the terrain between observer and target
+//
+// the grid is read once and sampled at roughly the grid resolution
+func (s *ElevationService) getGridProfile(ctx context.Context, path []elevation.Coordinate, spacing elevation.Spacing) ([]elevation.ProfilePoint, error) {
+	spacing, err := s.resolveSpacing(ctx, elevation.PathBoundingBox(path, 0), spacing)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.GetGrid(ctx, elevation.PathBoundingBox(path, 2*float64(spacing)), spacing)
+	if err != nil {
+		return nil, err
+	}
+	return g.Profile(path, spacingMeters(spacing)), nil
+}
+
+// check if target is visible from observer
+func (s *ElevationService) GetLineOfSight(ctx context.Context, observer elevation.Coordinate, target elevation.Coordinate, spacing elevation.Spacing, opts elevation.LineOfSightOptions) (elevation.LineOfSight, error) {
+	if opts.ObserverHeight < 0 || opts.TargetHeight < 0 {
+		return elevation.LineOfSight{}, invalidParameter("heights must not be negative")
+	}
+	if opts.Refraction < 0 || opts.Refraction >= 1 {
+		return elevation.LineOfSight{}, invalidParameter("invalid refraction coefficient: %f", opts.Refraction)
+	}
+	profile, err := s.getGridProfile(ctx, []elevation.Coordinate{observer, target}, spacing)
+	if err != nil {
+		return elevation.LineOfSight{}, err
+	}
+	if math.IsNaN(profile[0].Elevation) || math.IsNaN(profile[len(profile)-1].Elevation) {
+		return elevation.LineOfSight{}, fmt.Errorf("no data at observer or target")
+	}
+	return elevation.ComputeLineOfSight(profile, opts), nil
+}
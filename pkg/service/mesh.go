@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// triangulated terrain for the bounding box
+//
+// the grid is resampled to roughly resolution meters if that is coarser than spacing
+func (s *ElevationService) GetMesh(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, resolution float64, opts elevation.MeshOptions) (elevation.Mesh, error) {
+	if opts.Exaggeration <= 0 {
+		return elevation.Mesh{}, invalidParameter("invalid vertical exaggeration: %f", opts.Exaggeration)
+	}
+	if opts.Base < 0 {
+		return elevation.Mesh{}, invalidParameter("base must not be negative")
+	}
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return elevation.Mesh{}, err
+	}
+	if resolution > spacingMeters(g.Spacing) {
+		g = g.Resample(elevation.Spacing(resolution / spacingMeters(1)))
+	}
+	return g.Mesh(opts)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// peaks in the bounding box with at least minProminence meters of prominence
+//
+// prominence and isolation are measured inside of the bounding box only
+func (s *ElevationService) GetPeaks(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, minProminence float64) ([]elevation.Peak, error) {
+	if minProminence < 0 {
+		return nil, invalidParameter("invalid minimum prominence: %f", minProminence)
+	}
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	return g.Peaks(minProminence), nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// the most points a single profile may sample, each one is read from the db
+const MaxProfilePoints = 50_000
+
+// sample the elevation every interval meters along the path
+func (s *ElevationService) GetProfile(ctx context.Context, path []elevation.Coordinate, interval float64, spacing elevation.Spacing, interpolationMethod InterpolationMethod) ([]elevation.ProfilePoint, error) {
+	if len(path) < 2 {
+		return nil, invalidParameter("path must contain at least 2 points")
+	}
+	if interval <= 0 {
+		return nil, invalidParameter("invalid interval: %f", interval)
+	}
+	// Densify adds at most one point per segment on top of length / interval
+	if n := elevation.PathLength(path)/interval + float64(len(path)); n > MaxProfilePoints {
+		return nil, invalidParameter("interval too small: %.0f points along the path (max %d)", n, MaxProfilePoints)
+	}
+	profile := elevation.Densify(path, interval)
+	for i, p := range profile {
+		record, err := s.GetPointElevation(ctx, p.Latitude, p.Longitude, spacing, interpolationMethod)
+		if err != nil {
+			return nil, err
+		}
+		profile[i].Elevation = record.Elevation
+	}
+	return profile, nil
+}
@@ -4,16 +4,35 @@ import (
 	"context"
 	"elevation"
 	"elevation/pkg/db"
+	"errors"
 	"fmt"
 	"sort"
+	"sync"
 )
 
 type ElevationService struct {
 	db db.ElevationDB
+	// loaded tiles, nil until read
+	tiles   []elevation.DEMTile
+	tilesMu sync.Mutex
 }
 
 func NewElevationService(db db.ElevationDB) *ElevationService {
-	return &ElevationService{db}
+	return &ElevationService{db: db}
+}
+
+// matches errors caused by a request parameter rather than the data
+var ErrInvalidParameter = errors.New("invalid parameter")
+
+type invalidParameterError string
+
+func (e invalidParameterError) Error() string { return string(e) }
+
+func (e invalidParameterError) Is(target error) bool { return target == ErrInvalidParameter }
+
+// an error that matches ErrInvalidParameter with the formatted message
+func invalidParameter(format string, args ...any) error {
+	return invalidParameterError(fmt.Sprintf(format, args...))
 }
 
 type InterpolationMethod string
@@ -165,6 +184,10 @@ func bicubicInterpolation(lat float64, lng float64, records [16]elevation.HGTRec
 
 // Use the exported InterpolationMethod type
 func (s *ElevationService) GetPointElevation(ctx context.Context, lat float64, lng float64, spacing elevation.Spacing, interpolationMethod InterpolationMethod) (elevation.HGTRecord, error) {
+	spacing, err := s.resolveSpacing(ctx, elevation.BoundingBox{MinLatitude: lat, MinLongitude: lng, MaxLatitude: lat, MaxLongitude: lng}, spacing)
+	if err != nil {
+		return elevation.HGTRecord{}, err
+	}
 	switch interpolationMethod {
 	case NearestNeighbor:
 		return s.db.ReadNearestNeighbor(ctx, lat, lng)
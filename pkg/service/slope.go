@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"fmt"
+)
+
+// slope and aspect at lat,lng
+//
+// computed at the grid cell closest to lat,lng
+func (s *ElevationService) GetSlope(ctx context.Context, lat float64, lng float64, spacing elevation.Spacing, alg elevation.SlopeAlgorithm) (elevation.SlopeAspect, error) {
+	if err := validateSlopeAlgorithm(alg); err != nil {
+		return elevation.SlopeAspect{}, err
+	}
+	spacing, err := s.resolveSpacing(ctx, elevation.BoundingBox{MinLatitude: lat, MinLongitude: lng, MaxLatitude: lat, MaxLongitude: lng}, spacing)
+	if err != nil {
+		return elevation.SlopeAspect{}, err
+	}
+	// enough room for the 3x3 window around the closest cell
+	d := 2 * float64(spacing)
+	bbox := elevation.BoundingBox{MinLatitude: lat - d, MinLongitude: lng - d, MaxLatitude: lat + d, MaxLongitude: lng + d}
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return elevation.SlopeAspect{}, err
+	}
+	row, col := g.Cell(lat, lng)
+	slope, aspect, ok := g.SlopeAspect(row, col, alg)
+	if !ok {
+		return elevation.SlopeAspect{}, fmt.Errorf("no data around %f, %f", lat, lng)
+	}
+	return elevation.SlopeAspect{
+		Latitude:     lat,
+		Longitude:    lng,
+		SlopeDegrees: slope,
+		SlopePercent: elevation.SlopePercent(slope),
+		Aspect:       aspect,
+	}, nil
+}
+
+// slope (degrees) and aspect rasters for the bounding box
+func (s *ElevationService) GetSlopeGrid(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, alg elevation.SlopeAlgorithm) (*elevation.Grid, *elevation.Grid, error) {
+	if err := validateSlopeAlgorithm(alg); err != nil {
+		return nil, nil, err
+	}
+	spacing, err := s.resolveSpacing(ctx, bbox, spacing)
+	if err != nil {
+		return nil, nil, err
+	}
+	// buffer by one cell so the edges of the box have neighbors
+	g, err := s.GetGrid(ctx, bbox.Buffer(float64(spacing)), spacing)
+	if err != nil {
+		return nil, nil, err
+	}
+	slope, aspect := g.Slope(alg)
+	return slope.Crop(bbox), aspect.Crop(bbox), nil
+}
+
+func validateSlopeAlgorithm(alg elevation.SlopeAlgorithm) error {
+	switch alg {
+	case elevation.Horn, elevation.ZevenbergenThorne:
+		return nil
+	default:
+		return invalidParameter("invalid slope algorithm: %s", alg)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// the loaded tiles, read from the db on first use
+//
+// a failed read is not kept so the next call tries again
+func (s *ElevationService) loadedTiles(ctx context.Context) ([]elevation.DEMTile, error) {
+	s.tilesMu.Lock()
+	defer s.tilesMu.Unlock()
+	if s.tiles == nil {
+		tiles, err := s.db.ReadTiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.tiles = tiles
+	}
+	return s.tiles, nil
+}
+
+// the loaded tiles touching bbox
+func (s *ElevationService) tilesIn(ctx context.Context, bbox elevation.BoundingBox) ([]elevation.DEMTile, error) {
+	tiles, err := s.loadedTiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in := []elevation.DEMTile{}
+	for _, t := range tiles {
+		if float64(t.Latitude) > bbox.MaxLatitude || float64(t.Latitude+1) < bbox.MinLatitude ||
+			float64(t.Longitude) > bbox.MaxLongitude || float64(t.Longitude+1) < bbox.MinLongitude {
+			continue
+		}
+		in = append(in, t)
+	}
+	return in, nil
+}
+
+// finest spacing of the loaded tiles touching bbox
+//
+// SRTM1 when no loaded tile touches it, or the db was loaded before tiles were recorded.
+// grids read at this spacing interpolate the cells of coarser tiles, see GetGrid
+func (s *ElevationService) GetSpacing(ctx context.Context, bbox elevation.BoundingBox) (elevation.Spacing, error) {
+	tiles, err := s.tilesIn(ctx, bbox)
+	if err != nil {
+		return 0, err
+	}
+	spacing := elevation.Spacing(0)
+	for _, t := range tiles {
+		if t.Spacing > 0 && (spacing == 0 || t.Spacing < spacing) {
+			spacing = t.Spacing
+		}
+	}
+	if spacing == 0 {
+		return elevation.SRTM1, nil
+	}
+	return spacing, nil
+}
+
+// spacing, or the finest spacing of the loaded tiles touching bbox when it is 0
+func (s *ElevationService) resolveSpacing(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing) (elevation.Spacing, error) {
+	if spacing > 0 {
+		return spacing, nil
+	}
+	return s.GetSpacing(ctx, bbox)
+}
+
+// coarsest spacing of the loaded tiles touching bbox, 0 if there are none
+func (s *ElevationService) coarsestSpacing(ctx context.Context, bbox elevation.BoundingBox) (elevation.Spacing, error) {
+	tiles, err := s.tilesIn(ctx, bbox)
+	if err != nil {
+		return 0, err
+	}
+	spacing := elevation.Spacing(0)
+	for _, t := range tiles {
+		spacing = max(spacing, t.Spacing)
+	}
+	return spacing, nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// the most hypsometric histogram bands a single request may ask for
+const MaxHistogramBins = 1000
+
+// statistics of the elevations inside of polygons
+func (s *ElevationService) GetZonalStatistics(ctx context.Context, polygons [][][]elevation.Position, spacing elevation.Spacing, percentiles []float64, bins int) (elevation.ZonalStatistics, error) {
+	if bins < 1 || bins > MaxHistogramBins {
+		return elevation.ZonalStatistics{}, invalidParameter("invalid number of bins: %d (max %d)", bins, MaxHistogramBins)
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return elevation.ZonalStatistics{}, invalidParameter("invalid percentile: %g (must be 0-100)", p)
+		}
+	}
+	g, err := s.GetGrid(ctx, elevation.PolygonsBoundingBox(polygons), spacing)
+	if err != nil {
+		return elevation.ZonalStatistics{}, err
+	}
+	return g.ZonalStatistics(g.PolygonCells(polygons), percentiles, bins)
+}
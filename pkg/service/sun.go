@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"math"
+	"time"
+)
+
+// check if point is in the shadow of the terrain at t
+func (s *ElevationService) GetSunExposure(ctx context.Context, point elevation.Coordinate, t time.Time, spacing elevation.Spacing, opts elevation.HorizonOptions) (elevation.SunExposure, error) {
+	g, err := s.getHorizonGrid(ctx, point, spacing, opts)
+	if err != nil {
+		return elevation.SunExposure{}, err
+	}
+	return g.SunExposure(point, t, opts)
+}
+
+// hours of direct sunlight at point over the day of date
+func (s *ElevationService) GetSunHours(ctx context.Context, point elevation.Coordinate, date time.Time, interval time.Duration, spacing elevation.Spacing, opts elevation.HorizonOptions) (elevation.SunHours, error) {
+	if interval < time.Minute || interval > time.Hour {
+		return elevation.SunHours{}, invalidParameter("invalid interval: %s", interval)
+	}
+	horizon, err := s.GetHorizon(ctx, point, spacing, opts)
+	if err != nil {
+		return elevation.SunHours{}, err
+	}
+	return horizon.SunHours(date, interval), nil
+}
+
+// terrain shadows over bbox at t
+//
+// shadows are cast by terrain up to radius meters outside of the box
+func (s *ElevationService) GetShadow(ctx context.Context, bbox elevation.BoundingBox, t time.Time, radius float64, spacing elevation.Spacing) (*elevation.Grid, error) {
+	if radius < 0 {
+		return nil, invalidParameter("invalid radius: %f", radius)
+	}
+	lat := math.Max(math.Abs(bbox.MinLatitude), math.Abs(bbox.MaxLatitude))
+	buffer := radius / spacingMeters(1) / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+	g, err := s.GetGrid(ctx, bbox.Buffer(buffer), spacing)
+	if err != nil {
+		return nil, err
+	}
+	return g.Shadow(t, radius).Crop(bbox), nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// terrain index raster for the bounding box
+func (s *ElevationService) GetTerrainIndex(ctx context.Context, bbox elevation.BoundingBox, spacing elevation.Spacing, index elevation.TerrainIndex, opts elevation.TerrainOptions) (*elevation.Grid, error) {
+	buffer := 1
+	switch index {
+	case elevation.TRI, elevation.Roughness, elevation.ProfileCurvature, elevation.PlanCurvature:
+	case elevation.TPI:
+		if opts.InnerRadius < 0 || opts.OuterRadius <= opts.InnerRadius {
+			return nil, invalidParameter("invalid annulus: inner %d, outer %d", opts.InnerRadius, opts.OuterRadius)
+		}
+		buffer = opts.OuterRadius
+	default:
+		return nil, invalidParameter("invalid terrain index: %s", index)
+	}
+	spacing, err := s.resolveSpacing(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	// buffer so the edges of the box have complete neighborhoods
+	g, err := s.GetGrid(ctx, bbox.Buffer(float64(buffer)*float64(spacing)), spacing)
+	if err != nil {
+		return nil, err
+	}
+	return g.TerrainIndex(index, opts).Crop(bbox), nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"elevation"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// returned when there is no data under a tile
+var ErrEmptyTile = errors.New("no data in tile")
+
+// read the grid under a web mercator tile, with a small buffer so pixels on the edge can be interpolated
+func (s *ElevationService) getTileGrid(ctx context.Context, tile elevation.Tile, spacing elevation.Spacing) (*elevation.Grid, error) {
+	if err := tile.Validate(); err != nil {
+		return nil, err
+	}
+	bounds := tile.Bounds()
+	// the latitude extent is the smaller of the two away from the equator
+	resolution := math.Min(bounds.MaxLatitude-bounds.MinLatitude, bounds.MaxLongitude-bounds.MinLongitude) / elevation.TileSize
+	return s.getTileBoundsGrid(ctx, tile, bounds, spacing, resolution)
+}
+
+// read the grid under bounds of tile in any tiling scheme
+//
+// when resolution (degrees per pixel) is coarser than spacing the grid is read at a multiple of spacing
+// close to the resolution, so zoomed out tiles read about as many records as zoomed in ones
+func (s *ElevationService) getTileBoundsGrid(ctx context.Context, tile fmt.Stringer, bounds elevation.BoundingBox, spacing elevation.Spacing, resolution float64) (*elevation.Grid, error) {
+	spacing, err := s.resolveSpacing(ctx, bounds, spacing)
+	if err != nil {
+		return nil, err
+	}
+	step := max(1, int(resolution/float64(spacing)))
+	if step > 1 {
+		// coarser tiles only have records on multiples of their own spacing
+		coarsest, err := s.coarsestSpacing(ctx, bounds)
+		if err != nil {
+			return nil, err
+		}
+		if ratio := int(math.Round(float64(coarsest / spacing))); ratio > 1 {
+			step -= step % ratio
+			// 0 when finer than the coarse tiles, those are read at spacing and interpolated
+			step = max(step, 1)
+		}
+	}
+	cell := elevation.Spacing(float64(spacing) * float64(step))
+	bbox := bounds.Buffer(2 * float64(cell))
+	bbox.MinLatitude = math.Max(bbox.MinLatitude, -90)
+	bbox.MaxLatitude = math.Min(bbox.MaxLatitude, 90)
+	bbox.MinLongitude = math.Max(bbox.MinLongitude, -180)
+	bbox.MaxLongitude = math.Min(bbox.MaxLongitude, 180)
+	var g *elevation.Grid
+	if step == 1 {
+		var err error
+		if g, err = s.GetGrid(ctx, bbox, spacing); err != nil {
+			return nil, err
+		}
+	} else {
+		records, err := s.db.ReadBoundingBoxStep(ctx, bbox, spacing, step)
+		if err != nil {
+			return nil, err
+		}
+		g = elevation.NewGridFromRecords(bbox, cell, records)
+	}
+	if lo, _ := g.MinMax(); math.IsNaN(lo) {
+		return nil, fmt.Errorf("tile %s: %w", tile, ErrEmptyTile)
+	}
+	return g, nil
+}
+
+// png elevation tile for Terrain-RGB or Terrarium clients
+func (s *ElevationService) GetElevationTile(ctx context.Context, tile elevation.Tile, encoding elevation.TileEncoding, spacing elevation.Spacing) ([]byte, error) {
+	g, err := s.getTileGrid(ctx, tile, spacing)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := elevation.ElevationTileToPNG(&buf, g.TileElevations(tile, elevation.Bilinear), encoding); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// grayscale hillshade tile
+func (s *ElevationService) GetHillshadeTile(ctx context.Context, tile elevation.Tile, opts elevation.HillshadeOptions, spacing elevation.Spacing) ([]byte, error) {
+	if opts.Altitude < 0 || opts.Altitude > 90 {
+		return nil, invalidParameter("invalid altitude: %f", opts.Altitude)
+	}
+	g, err := s.getTileGrid(ctx, tile, spacing)
+	if err != nil {
+		return nil, err
+	}
+	shades := g.Hillshade(opts).TileElevations(tile, elevation.Bilinear)
+	colors := make([]color.NRGBA, len(shades))
+	for i, v := range shades {
+		colors[i] = elevation.HillshadeColor(v)
+	}
+	var buf bytes.Buffer
+	if err := elevation.TileToPNG(&buf, colors); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// color relief tile, optionally shaded by a hillshade
+func (s *ElevationService) GetReliefTile(ctx context.Context, tile elevation.Tile, ramp elevation.ColorRamp, shade *elevation.HillshadeOptions, spacing elevation.Spacing) ([]byte, error) {
+	if shade != nil && (shade.Altitude < 0 || shade.Altitude > 90) {
+		return nil, invalidParameter("invalid altitude: %f", shade.Altitude)
+	}
+	g, err := s.getTileGrid(ctx, tile, spacing)
+	if err != nil {
+		return nil, err
+	}
+	elevs := g.TileElevations(tile, elevation.Bilinear)
+	colors := make([]color.NRGBA, len(elevs))
+	for i, v := range elevs {
+		colors[i] = ramp.At(v)
+	}
+	if shade != nil {
+		for i, v := range g.Hillshade(*shade).TileElevations(tile, elevation.Bilinear) {
+			colors[i] = elevation.ShadeColor(colors[i], v)
+		}
+	}
+	var buf bytes.Buffer
+	if err := elevation.TileToPNG(&buf, colors); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cesium quantized mesh terrain tile
+//
+// tiles with no data are flat at sea level
+func (s *ElevationService) GetQuantizedMeshTile(ctx context.Context, tile elevation.GeographicTile, normals bool, spacing elevation.Spacing) ([]byte, error) {
+	if err := tile.Validate(); err != nil {
+		return nil, err
+	}
+	bounds := tile.Bounds()
+	resolution := (bounds.MaxLatitude - bounds.MinLatitude) / (elevation.QuantizedMeshSize - 1)
+	g, err := s.getTileBoundsGrid(ctx, tile, bounds, spacing, resolution)
+	if errors.Is(err, ErrEmptyTile) {
+		g, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return elevation.QuantizedMesh(g, tile, normals)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"elevation"
+	"math"
+)
+
+// area visible from observer
+func (s *ElevationService) GetViewshed(ctx context.Context, observer elevation.Coordinate, spacing elevation.Spacing, opts elevation.ViewshedOptions) (*elevation.Grid, error) {
+	if opts.Radius <= 0 {
+		return nil, invalidParameter("invalid radius: %f", opts.Radius)
+	}
+	if opts.ObserverHeight < 0 || opts.TargetHeight < 0 {
+		return nil, invalidParameter("heights must not be negative")
+	}
+	if opts.Refraction < 0 || opts.Refraction >= 1 {
+		return nil, invalidParameter("invalid refraction coefficient: %f", opts.Refraction)
+	}
+	dLat := opts.Radius / spacingMeters(1)
+	dLng := dLat / math.Max(math.Cos(observer.Latitude*math.Pi/180), 0.01)
+	bbox := elevation.BoundingBox{
+		MinLatitude:  observer.Latitude - dLat,
+		MinLongitude: observer.Longitude - dLng,
+		MaxLatitude:  observer.Latitude + dLat,
+		MaxLongitude: observer.Longitude + dLng,
+	}
+	g, err := s.GetGrid(ctx, bbox, spacing)
+	if err != nil {
+		return nil, err
+	}
+	return g.Viewshed(observer, opts)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"elevation"
+)
+
+// the default distance (degrees) searched around a pour point when no bounding box is given
+const DefaultWatershedExtent = 0.1
+
+// the most cells searched around a pour point for the highest accumulation
+const MaxWatershedSnap = 100
+
+// delineate the catchment draining through pour
+//
+// flow is only routed inside of bbox. if the catchment reaches the edge of bbox it is marked as truncated
+func (s *ElevationService) GetWatershed(ctx context.Context, pour elevation.Coordinate, bbox elevation.BoundingBox, spacing elevation.Spacing, snap int) (elevation.Watershed, error) {
+	if !bbox.Contains(pour.Latitude, pour.Longitude) {
+		return elevation.Watershed{}, invalidParameter("pour point must be inside of the bounding box")
+	}
+	if snap < 0 || snap > MaxWatershedSnap {
+		return elevation.Watershed{}, invalidParameter("invalid snap distance: %d (max %d)", snap, MaxWatershedSnap)
+	}
+	h, err := s.GetHydrology(ctx, bbox, spacing)
+	if err != nil {
+		return elevation.Watershed{}, err
+	}
+	return h.Watershed(pour, snap)
+}
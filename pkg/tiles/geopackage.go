@@ -0,0 +1,463 @@
+package tiles
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"elevation"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+
+	_ "modernc.org/sqlite"
+)
+
+// how the tiles of a gridded coverage are encoded
+type CoverageEncoding string
+
+const (
+	// 16 bit grayscale png, with a per tile offset
+	CoveragePNG CoverageEncoding = "png"
+	// 32 bit float tiff
+	CoverageTIFF CoverageEncoding = "tiff"
+)
+
+// width and height of gridded coverage tiles in pixels
+//
+// 240 divides both 3600 and 1200 so tiles line up with the 1 degree srtm files
+const CoverageTileSize = 240
+
+// png pixel value for voids
+const pngNull = math.MaxUint16
+
+// the 2D gridded coverage extension, see http://docs.opengeospatial.org/is/17-066r1/17-066r1.html
+const griddedCoverageExtension = "gpkg_2d_gridded_coverage"
+const griddedCoverageDefinition = "http://docs.opengeospatial.org/is/17-066r1/17-066r1.html"
+
+const wgs84WKT = `GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]`
+
+const geoPackageSchema = `
+create table if not exists gpkg_spatial_ref_sys (
+	srs_name text not null,
+	srs_id integer not null primary key,
+	organization text not null,
+	organization_coordsys_id integer not null,
+	definition text not null,
+	description text
+);
+create table if not exists gpkg_contents (
+	table_name text not null primary key,
+	data_type text not null,
+	identifier text unique,
+	description text default '',
+	last_change datetime not null default (strftime('%Y-%m-%dT%H:%M:%fZ','now')),
+	min_x double,
+	min_y double,
+	max_x double,
+	max_y double,
+	srs_id integer,
+	constraint fk_gc_r_srs_id foreign key (srs_id) references gpkg_spatial_ref_sys(srs_id)
+);
+create table if not exists gpkg_tile_matrix_set (
+	table_name text not null primary key,
+	srs_id integer not null,
+	min_x double not null,
+	min_y double not null,
+	max_x double not null,
+	max_y double not null,
+	constraint fk_gtms_table_name foreign key (table_name) references gpkg_contents(table_name),
+	constraint fk_gtms_srs foreign key (srs_id) references gpkg_spatial_ref_sys (srs_id)
+);
+create table if not exists gpkg_tile_matrix (
+	table_name text not null,
+	zoom_level integer not null,
+	matrix_width integer not null,
+	matrix_height integer not null,
+	tile_width integer not null,
+	tile_height integer not null,
+	pixel_x_size double not null,
+	pixel_y_size double not null,
+	constraint pk_ttm primary key (table_name, zoom_level),
+	constraint fk_tmm_table_name foreign key (table_name) references gpkg_contents(table_name)
+);
+create table if not exists gpkg_extensions (
+	table_name text,
+	column_name text,
+	extension_name text not null,
+	definition text not null,
+	scope text not null,
+	constraint ge_tce unique (table_name, column_name, extension_name)
+);
+create table if not exists gpkg_2d_gridded_coverage_ancillary (
+	id integer primary key autoincrement,
+	tile_matrix_set_name text not null unique,
+	datatype text not null default 'integer',
+	scale real not null default 1.0,
+	offset real not null default 0.0,
+	precision real default 1.0,
+	data_null real,
+	grid_cell_encoding text default 'grid-value-is-center',
+	uom text,
+	field_name text default 'Height',
+	quantity_definition text default 'Height',
+	constraint fk_g2dgtct_name foreign key (tile_matrix_set_name) references gpkg_tile_matrix_set (table_name),
+	check (datatype in ('integer','float'))
+);
+create table if not exists gpkg_2d_gridded_tile_ancillary (
+	id integer primary key autoincrement,
+	tpudt_name text not null,
+	tpudt_id integer not null,
+	scale real not null default 1.0,
+	offset real not null default 0.0,
+	min real default null,
+	max real default null,
+	mean real default null,
+	std_dev real default null,
+	constraint fk_g2dgtat_name foreign key (tpudt_name) references gpkg_contents(table_name),
+	unique (tpudt_name, tpudt_id)
+);
+insert or ignore into gpkg_spatial_ref_sys values ('Undefined cartesian SRS', -1, 'NONE', -1, 'undefined', 'undefined cartesian coordinate reference system');
+insert or ignore into gpkg_spatial_ref_sys values ('Undefined geographic SRS', 0, 'NONE', 0, 'undefined', 'undefined geographic coordinate reference system');
+insert or ignore into gpkg_spatial_ref_sys values ('WGS 84 geodetic', 4326, 'EPSG', 4326, '` + wgs84WKT + `', 'longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid');
+`
+
+// a GeoPackage 1.2 with a single elevation layer in the 2D gridded coverage extension
+//
+// the tile matrix covers the whole world at one zoom level with one pixel per grid cell,
+// centered on the cells (grid-value-is-center). tiles are only stored where there is data
+type GeoPackage struct {
+	db       *sql.DB
+	table    string
+	spacing  elevation.Spacing
+	encoding CoverageEncoding
+	// extent of the written data, added to gpkg_contents on close
+	bounds *elevation.BoundingBox
+}
+
+// open or create the geopackage at path with an elevation layer named table
+//
+// an existing layer must have the same spacing and encoding. new data is merged into it
+func OpenGeoPackage(path string, table string, spacing elevation.Spacing, encoding CoverageEncoding) (*GeoPackage, error) {
+	if encoding != CoveragePNG && encoding != CoverageTIFF {
+		return nil, fmt.Errorf("invalid tile encoding: %s", encoding)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	g := &GeoPackage{db: db, table: table, spacing: spacing, encoding: encoding}
+	if err := g.setup(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// world extent of the tile matrix: the pixel edges around the cells from -180,90
+func (g *GeoPackage) matrix() (elevation.BoundingBox, int, int) {
+	s := float64(g.spacing)
+	width := int(math.Ceil(360/s/CoverageTileSize - 1e-9))
+	height := int(math.Ceil(180/s/CoverageTileSize - 1e-9))
+	west, north := -180-s/2, 90+s/2
+	return elevation.BoundingBox{
+		MinLatitude:  north - float64(height*CoverageTileSize)*s,
+		MinLongitude: west,
+		MaxLatitude:  north,
+		MaxLongitude: west + float64(width*CoverageTileSize)*s,
+	}, width, height
+}
+
+func (g *GeoPackage) setup() error {
+	stmts := []string{
+		"PRAGMA application_id = 1196444487;", // GPKG
+		"PRAGMA user_version = 10200;",
+		"PRAGMA synchronous = OFF;",
+		geoPackageSchema,
+	}
+	for _, stmt := range stmts {
+		if _, err := g.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	var datatype string
+	var pixelSize float64
+	err := g.db.QueryRow(`
+select a.datatype, m.pixel_x_size
+from gpkg_2d_gridded_coverage_ancillary a
+join gpkg_tile_matrix m on m.table_name = a.tile_matrix_set_name
+where a.tile_matrix_set_name = ? and m.zoom_level = 0;`, g.table).Scan(&datatype, &pixelSize)
+	if err == nil {
+		if math.Abs(pixelSize-float64(g.spacing)) > 1e-12 {
+			return fmt.Errorf("layer %s has a spacing of %g, not %g", g.table, pixelSize, float64(g.spacing))
+		}
+		if (datatype == "integer") != (g.encoding == CoveragePNG) {
+			return fmt.Errorf("layer %s has %s tiles, not %s", g.table, datatype, g.encoding)
+		}
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	// new layer
+	bounds, width, height := g.matrix()
+	s := float64(g.spacing)
+	datatype = "integer"
+	dataNull := float64(pngNull)
+	if g.encoding == CoverageTIFF {
+		datatype = "float"
+		dataNull = elevation.NoData
+	}
+	tx, err := g.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(fmt.Sprintf(`create table %q (
+	id integer primary key autoincrement,
+	zoom_level integer not null,
+	tile_column integer not null,
+	tile_row integer not null,
+	tile_data blob not null,
+	unique (zoom_level, tile_column, tile_row)
+);`, g.table))
+	if err != nil {
+		return err
+	}
+	inserts := []struct {
+		q    string
+		args []any
+	}{
+		{"insert into gpkg_contents (table_name, data_type, identifier, description, srs_id) values (?, '2d-gridded-coverage', ?, 'elevation (m)', 4326);",
+			[]any{g.table, g.table}},
+		{"insert into gpkg_tile_matrix_set values (?, 4326, ?, ?, ?, ?);",
+			[]any{g.table, bounds.MinLongitude, bounds.MinLatitude, bounds.MaxLongitude, bounds.MaxLatitude}},
+		{"insert into gpkg_tile_matrix values (?, 0, ?, ?, ?, ?, ?, ?);",
+			[]any{g.table, width, height, CoverageTileSize, CoverageTileSize, s, s}},
+		{`insert into gpkg_2d_gridded_coverage_ancillary
+(tile_matrix_set_name, datatype, scale, offset, precision, data_null, grid_cell_encoding, uom, field_name, quantity_definition)
+values (?, ?, 1.0, 0.0, 1.0, ?, 'grid-value-is-center', 'm', 'Height', 'Height');`,
+			[]any{g.table, datatype, dataNull}},
+		{"insert or ignore into gpkg_extensions values ('gpkg_2d_gridded_coverage_ancillary', null, ?, ?, 'read-write');",
+			[]any{griddedCoverageExtension, griddedCoverageDefinition}},
+		{"insert or ignore into gpkg_extensions values ('gpkg_2d_gridded_tile_ancillary', null, ?, ?, 'read-write');",
+			[]any{griddedCoverageExtension, griddedCoverageDefinition}},
+		{"insert or ignore into gpkg_extensions values (?, 'tile_data', ?, ?, 'read-write');",
+			[]any{g.table, griddedCoverageExtension, griddedCoverageDefinition}},
+	}
+	for _, insert := range inserts {
+		if _, err := tx.Exec(insert.q, insert.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// write the cells of the grid, merging them into tiles that already have data
+//
+// the grid must have the spacing of the layer
+func (g *GeoPackage) WriteGrid(ctx context.Context, grid *elevation.Grid) error {
+	s := float64(g.spacing)
+	if math.Abs(float64(grid.Spacing)-s) > 1e-12 {
+		return fmt.Errorf("grid spacing %g does not match the layer spacing %g", float64(grid.Spacing), s)
+	}
+	_, width, height := g.matrix()
+	// global pixel of the first cell
+	px0 := int(math.Round((grid.West + 180) / s))
+	py0 := int(math.Round((90 - grid.North) / s))
+	px1 := min(px0+grid.Cols, width*CoverageTileSize) - 1
+	py1 := min(py0+grid.Rows, height*CoverageTileSize) - 1
+	if px1 < 0 || py1 < 0 {
+		return nil
+	}
+
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	wrote := false
+	for row := max(py0, 0) / CoverageTileSize; row <= py1/CoverageTileSize; row++ {
+		for col := max(px0, 0) / CoverageTileSize; col <= px1/CoverageTileSize; col++ {
+			values := make([]float64, CoverageTileSize*CoverageTileSize)
+			empty := true
+			for y := range CoverageTileSize {
+				for x := range CoverageTileSize {
+					v := grid.At(row*CoverageTileSize+y-py0, col*CoverageTileSize+x-px0)
+					values[y*CoverageTileSize+x] = v
+					empty = empty && math.IsNaN(v)
+				}
+			}
+			if empty {
+				continue
+			}
+			if err := g.putTile(ctx, tx, col, row, values); err != nil {
+				return err
+			}
+			wrote = true
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if wrote {
+		extent := elevation.BoundingBox{
+			MinLatitude:  grid.North - float64(grid.Rows-1)*s - s/2,
+			MinLongitude: grid.West - s/2,
+			MaxLatitude:  grid.North + s/2,
+			MaxLongitude: grid.West + float64(grid.Cols-1)*s + s/2,
+		}
+		if g.bounds == nil {
+			g.bounds = &extent
+		} else {
+			g.bounds.MinLatitude = math.Min(g.bounds.MinLatitude, extent.MinLatitude)
+			g.bounds.MinLongitude = math.Min(g.bounds.MinLongitude, extent.MinLongitude)
+			g.bounds.MaxLatitude = math.Max(g.bounds.MaxLatitude, extent.MaxLatitude)
+			g.bounds.MaxLongitude = math.Max(g.bounds.MaxLongitude, extent.MaxLongitude)
+		}
+	}
+	return nil
+}
+
+// insert the tile, or fill the voids of the existing one
+func (g *GeoPackage) putTile(ctx context.Context, tx *sql.Tx, col int, row int, values []float64) error {
+	var id int64
+	var existing []byte
+	var offset float64
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+select t.id, t.tile_data, coalesce(a.offset, 0)
+from %q t
+left join gpkg_2d_gridded_tile_ancillary a on a.tpudt_name = ? and a.tpudt_id = t.id
+where t.zoom_level = 0 and t.tile_column = ? and t.tile_row = ?;`, g.table), g.table, col, row).Scan(&id, &existing, &offset)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		old, err := g.decodeTile(existing, offset)
+		if err != nil {
+			return fmt.Errorf("tile %d/%d: %w", col, row, err)
+		}
+		for i, v := range old {
+			if !math.IsNaN(v) {
+				values[i] = v
+			}
+		}
+	}
+
+	stats := tileStatistics(values)
+	data, offset, err := g.encodeTile(values, stats.Min)
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf("insert into %q (zoom_level, tile_column, tile_row, tile_data) values (0, ?, ?, ?);", g.table), col, row, data)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	} else if _, err := tx.ExecContext(ctx, fmt.Sprintf("update %q set tile_data = ? where id = ?;", g.table), data, id); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+insert or replace into gpkg_2d_gridded_tile_ancillary (tpudt_name, tpudt_id, scale, offset, min, max, mean, std_dev)
+values (?, ?, 1.0, ?, ?, ?, ?, ?);`, g.table, id, offset, stats.Min, stats.Max, stats.Mean, stats.StdDev)
+	return err
+}
+
+// summary of the values that are not voids
+func tileStatistics(values []float64) elevation.Statistics {
+	lo, hi, sum, sumSq, n := math.Inf(1), math.Inf(-1), 0.0, 0.0, 0
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+		sum += v
+		sumSq += v * v
+		n++
+	}
+	mean := sum / float64(n)
+	return elevation.Statistics{Min: lo, Max: hi, Mean: mean, StdDev: math.Sqrt(math.Max(0, sumSq/float64(n)-mean*mean))}
+}
+
+// encode the values, returning the tile offset
+//
+// png pixels are meters above the lowest value in the tile
+func (g *GeoPackage) encodeTile(values []float64, lo float64) ([]byte, float64, error) {
+	var buf bytes.Buffer
+	if g.encoding == CoverageTIFF {
+		err := elevation.FloatsToTIFF(&buf, CoverageTileSize, CoverageTileSize, values)
+		return buf.Bytes(), 0, err
+	}
+	offset := math.Floor(lo)
+	img := image.NewGray16(image.Rect(0, 0, CoverageTileSize, CoverageTileSize))
+	for i, v := range values {
+		p := uint16(pngNull)
+		if !math.IsNaN(v) {
+			p = uint16(math.Max(0, math.Min(pngNull-1, math.Round(v-offset))))
+		}
+		img.Pix[2*i] = uint8(p >> 8)
+		img.Pix[2*i+1] = uint8(p)
+	}
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), offset, err
+}
+
+// decode a tile written by encodeTile. voids are NaN
+func (g *GeoPackage) decodeTile(data []byte, offset float64) ([]float64, error) {
+	if g.encoding == CoverageTIFF {
+		width, height, values, err := elevation.TIFFToFloats(data)
+		if err != nil {
+			return nil, err
+		}
+		if width != CoverageTileSize || height != CoverageTileSize {
+			return nil, fmt.Errorf("unexpected tile size %dx%d", width, height)
+		}
+		return values, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	gray, ok := img.(*image.Gray16)
+	if !ok || gray.Rect.Dx() != CoverageTileSize || gray.Rect.Dy() != CoverageTileSize {
+		return nil, fmt.Errorf("unexpected tile image")
+	}
+	values := make([]float64, CoverageTileSize*CoverageTileSize)
+	for i := range values {
+		p := uint16(gray.Pix[2*i])<<8 | uint16(gray.Pix[2*i+1])
+		if p == pngNull {
+			values[i] = math.NaN()
+		} else {
+			values[i] = float64(p) + offset
+		}
+	}
+	return values, nil
+}
+
+// record the extent of the data in gpkg_contents and close the file
+func (g *GeoPackage) Close() error {
+	if g.bounds != nil {
+		b := *g.bounds
+		_, err := g.db.Exec(`
+update gpkg_contents set
+	min_x = min(coalesce(min_x, ?), ?),
+	min_y = min(coalesce(min_y, ?), ?),
+	max_x = max(coalesce(max_x, ?), ?),
+	max_y = max(coalesce(max_y, ?), ?),
+	last_change = strftime('%Y-%m-%dT%H:%M:%fZ','now')
+where table_name = ?;`,
+			b.MinLongitude, b.MinLongitude, b.MinLatitude, b.MinLatitude,
+			b.MaxLongitude, b.MaxLongitude, b.MaxLatitude, b.MaxLatitude, g.table)
+		if err != nil {
+			g.db.Close()
+			return err
+		}
+	}
+	return g.db.Close()
+}
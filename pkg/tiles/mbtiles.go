@@ -0,0 +1,105 @@
+package tiles
+
+import (
+	"context"
+	"database/sql"
+	"elevation"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// an MBTiles 1.3 sqlite archive
+//
+// tiles are stored with TMS rows (y increases to the north)
+type MBTiles struct {
+	db *sql.DB
+}
+
+// open or create the archive at path
+//
+// existing tiles are kept so that an interrupted run can be resumed
+func OpenMBTiles(path string) (*MBTiles, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// one connection so reads never race a write
+	db.SetMaxOpenConns(1)
+	stmts := []string{
+		"PRAGMA journal_mode = WAL;",
+		"PRAGMA synchronous = OFF;",
+		"create table if not exists metadata (name text, value text, primary key (name));",
+		"create table if not exists tiles (zoom_level integer, tile_column integer, tile_row integer, tile_data blob, primary key (zoom_level, tile_column, tile_row));",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &MBTiles{db: db}, nil
+}
+
+func (m *MBTiles) Close() error {
+	return m.db.Close()
+}
+
+// mbtiles rows count from the south
+func tmsRow(t elevation.Tile) int {
+	return (1 << t.Z) - 1 - t.Y
+}
+
+// set metadata values, replacing existing ones
+func (m *MBTiles) SetMetadata(ctx context.Context, metadata map[string]string) error {
+	for name, value := range metadata {
+		if _, err := m.db.ExecContext(ctx, "insert or replace into metadata (name, value) values (?, ?);", name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read the data for t, or nil if it is not in the archive
+func (m *MBTiles) Get(ctx context.Context, t elevation.Tile) ([]byte, error) {
+	var data []byte
+	err := m.db.QueryRowContext(ctx, "select tile_data from tiles where zoom_level = ? and tile_column = ? and tile_row = ?;", t.Z, t.X, tmsRow(t)).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+// true if t is already in the archive
+func (m *MBTiles) Has(ctx context.Context, t elevation.Tile) (bool, error) {
+	var n int
+	err := m.db.QueryRowContext(ctx, "select count(*) from tiles where zoom_level = ? and tile_column = ? and tile_row = ?;", t.Z, t.X, tmsRow(t)).Scan(&n)
+	return n > 0, err
+}
+
+func (m *MBTiles) Put(ctx context.Context, t elevation.Tile, data []byte) error {
+	_, err := m.db.ExecContext(ctx, "insert or replace into tiles (zoom_level, tile_column, tile_row, tile_data) values (?, ?, ?, ?);", t.Z, t.X, tmsRow(t), data)
+	if err != nil {
+		return fmt.Errorf("tile %s: %v", t, err)
+	}
+	return nil
+}
+
+// every tile in the archive
+func (m *MBTiles) Tiles(ctx context.Context) ([]elevation.Tile, error) {
+	rows, err := m.db.QueryContext(ctx, "select zoom_level, tile_column, tile_row from tiles;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tiles := []elevation.Tile{}
+	for rows.Next() {
+		var t elevation.Tile
+		if err := rows.Scan(&t.Z, &t.X, &t.Y); err != nil {
+			return nil, err
+		}
+		t.Y = tmsRow(t)
+		tiles = append(tiles, t)
+	}
+	return tiles, rows.Err()
+}
@@ -0,0 +1,201 @@
+package tiles
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"elevation"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+)
+
+// PMTiles v3 constants
+const (
+	pmtilesHeaderSize   = 127
+	pmtilesRootMaxSize  = 16384 - pmtilesHeaderSize
+	pmtilesGzip         = 2
+	pmtilesNone         = 1
+	pmtilesTileTypePNG  = 2
+	pmtilesMaxLeafSize  = 1 << 20
+	pmtilesMinLeafCount = 4096
+)
+
+// the position of a tile on the hilbert curve of its zoom level, offset by the tiles of lower zooms
+func TileID(t elevation.Tile) uint64 {
+	id := uint64((1<<(2*t.Z))-1) / 3
+	x, y := uint32(t.X), uint32(t.Y)
+	for s := uint32(1) << t.Z >> 1; s > 0; s >>= 1 {
+		rx, ry := uint32(0), uint32(0)
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+		id += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+		// rotate the quadrant
+		if ry == 0 {
+			if rx == 1 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+	}
+	return id
+}
+
+type pmtilesEntry struct {
+	id     uint64
+	offset uint64
+	length uint32
+	run    uint32
+}
+
+// serialize and gzip a directory
+func encodeDirectory(entries []pmtilesEntry) ([]byte, error) {
+	buf := binary.AppendUvarint(nil, uint64(len(entries)))
+	last := uint64(0)
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, e.id-last)
+		last = e.id
+	}
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(e.run))
+	}
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(e.length))
+	}
+	for i, e := range entries {
+		if i > 0 && e.offset == entries[i-1].offset+uint64(entries[i-1].length) {
+			buf = binary.AppendUvarint(buf, 0)
+		} else {
+			buf = binary.AppendUvarint(buf, e.offset+1)
+		}
+	}
+	return gzipBytes(buf)
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// split entries into leaf directories until the root fits in the first 16 KiB
+func buildDirectories(entries []pmtilesEntry) ([]byte, []byte, error) {
+	root, err := encodeDirectory(entries)
+	if err != nil || len(root) <= pmtilesRootMaxSize {
+		return root, nil, err
+	}
+	for leafSize := pmtilesMinLeafCount; leafSize <= pmtilesMaxLeafSize; leafSize *= 2 {
+		rootEntries := []pmtilesEntry{}
+		leaves := []byte{}
+		for i := 0; i < len(entries); i += leafSize {
+			leaf, err := encodeDirectory(entries[i:min(i+leafSize, len(entries))])
+			if err != nil {
+				return nil, nil, err
+			}
+			rootEntries = append(rootEntries, pmtilesEntry{id: entries[i].id, offset: uint64(len(leaves)), length: uint32(len(leaf))})
+			leaves = append(leaves, leaf...)
+		}
+		if root, err = encodeDirectory(rootEntries); err != nil {
+			return nil, nil, err
+		}
+		if len(root) <= pmtilesRootMaxSize {
+			return root, leaves, nil
+		}
+	}
+	return nil, nil, io.ErrShortBuffer
+}
+
+// write every tile in src to w as a single PMTiles v3 archive of png tiles
+//
+// bbox and the zoom range are recorded in the header. metadata is written as the json metadata
+func WritePMTiles(ctx context.Context, w io.Writer, src *MBTiles, bbox elevation.BoundingBox, minZoom int, maxZoom int, metadata map[string]string) error {
+	tiles, err := src.Tiles(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(tiles, func(i int, j int) bool { return TileID(tiles[i]) < TileID(tiles[j]) })
+
+	// first pass for the length of every tile so the directories can be written up front
+	entries := make([]pmtilesEntry, len(tiles))
+	offset := uint64(0)
+	for i, t := range tiles {
+		data, err := src.Get(ctx, t)
+		if err != nil {
+			return err
+		}
+		entries[i] = pmtilesEntry{id: TileID(t), offset: offset, length: uint32(len(data)), run: 1}
+		offset += uint64(len(data))
+	}
+	root, leaves, err := buildDirectories(entries)
+	if err != nil {
+		return err
+	}
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if meta, err = gzipBytes(meta); err != nil {
+		return err
+	}
+
+	header := make([]byte, pmtilesHeaderSize)
+	copy(header, "PMTiles")
+	header[7] = 3
+	rootOffset := uint64(pmtilesHeaderSize)
+	metaOffset := rootOffset + uint64(len(root))
+	leafOffset := metaOffset + uint64(len(meta))
+	dataOffset := leafOffset + uint64(len(leaves))
+	for i, v := range []uint64{
+		rootOffset, uint64(len(root)),
+		metaOffset, uint64(len(meta)),
+		leafOffset, uint64(len(leaves)),
+		dataOffset, offset,
+		uint64(len(entries)), uint64(len(entries)), uint64(len(entries)),
+	} {
+		binary.LittleEndian.PutUint64(header[8+8*i:], v)
+	}
+	header[96] = 1 // clustered
+	header[97] = pmtilesGzip
+	header[98] = pmtilesNone
+	header[99] = pmtilesTileTypePNG
+	header[100] = uint8(minZoom)
+	header[101] = uint8(maxZoom)
+	e7 := func(deg float64) uint32 { return uint32(int32(math.Round(deg * 1e7))) }
+	binary.LittleEndian.PutUint32(header[102:], e7(bbox.MinLongitude))
+	binary.LittleEndian.PutUint32(header[106:], e7(bbox.MinLatitude))
+	binary.LittleEndian.PutUint32(header[110:], e7(bbox.MaxLongitude))
+	binary.LittleEndian.PutUint32(header[114:], e7(bbox.MaxLatitude))
+	header[118] = uint8(minZoom)
+	binary.LittleEndian.PutUint32(header[119:], e7((bbox.MinLongitude+bbox.MaxLongitude)/2))
+	binary.LittleEndian.PutUint32(header[123:], e7((bbox.MinLatitude+bbox.MaxLatitude)/2))
+
+	bw := bufio.NewWriter(w)
+	for _, b := range [][]byte{header, root, meta, leaves} {
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+	for _, t := range tiles {
+		data, err := src.Get(ctx, t)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,124 @@
+package elevation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// read the polygons out of a GeoJSON Polygon, MultiPolygon, Feature or FeatureCollection
+//
+// other geometry types are ignored. it is an error if no polygons are found
+func ParsePolygons(data []byte) ([][][]Position, error) {
+	polygons, err := parsePolygons(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("no polygons found")
+	}
+	for _, p := range polygons {
+		if len(p) == 0 || len(p[0]) < 4 {
+			return nil, fmt.Errorf("invalid polygon: rings must have at least 4 positions")
+		}
+	}
+	return polygons, nil
+}
+
+func parsePolygons(data []byte) ([][][]Position, error) {
+	var obj struct {
+		Type        string            `json:"type"`
+		Coordinates json.RawMessage   `json:"coordinates"`
+		Geometry    json.RawMessage   `json:"geometry"`
+		Features    []json.RawMessage `json:"features"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid geojson: %v", err)
+	}
+	switch obj.Type {
+	case "Polygon":
+		var p [][]Position
+		if err := json.Unmarshal(obj.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid polygon: %v", err)
+		}
+		return [][][]Position{p}, nil
+	case "MultiPolygon":
+		var mp [][][]Position
+		if err := json.Unmarshal(obj.Coordinates, &mp); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon: %v", err)
+		}
+		return mp, nil
+	case "Feature":
+		if len(obj.Geometry) == 0 || string(obj.Geometry) == "null" {
+			return nil, nil
+		}
+		return parsePolygons(obj.Geometry)
+	case "FeatureCollection":
+		polygons := [][][]Position{}
+		for _, f := range obj.Features {
+			p, err := parsePolygons(f)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, p...)
+		}
+		return polygons, nil
+	case "":
+		return nil, fmt.Errorf("invalid geojson: missing type")
+	default:
+		return nil, nil
+	}
+}
+
+// bounding box of every position in the polygons
+func PolygonsBoundingBox(polygons [][][]Position) BoundingBox {
+	b := BoundingBox{
+		MinLatitude:  math.Inf(1),
+		MinLongitude: math.Inf(1),
+		MaxLatitude:  math.Inf(-1),
+		MaxLongitude: math.Inf(-1),
+	}
+	for _, p := range polygons {
+		for _, ring := range p {
+			for _, pos := range ring {
+				b.MinLongitude = math.Min(b.MinLongitude, pos[0])
+				b.MaxLongitude = math.Max(b.MaxLongitude, pos[0])
+				b.MinLatitude = math.Min(b.MinLatitude, pos[1])
+				b.MaxLatitude = math.Max(b.MaxLatitude, pos[1])
+			}
+		}
+	}
+	return b
+}
+
+// mark the cells whose centers fall inside of the polygons, indexed like Data
+//
+// each row is scanned with the even-odd rule so holes are excluded
+func (g *Grid) PolygonCells(polygons [][][]Position) []bool {
+	inside := make([]bool, len(g.Data))
+	s := float64(g.Spacing)
+	for row := range g.Rows {
+		lat := g.Latitude(row)
+		for _, p := range polygons {
+			crossings := []float64{}
+			for _, ring := range p {
+				for k := 0; k < len(ring)-1; k++ {
+					a, b := ring[k], ring[k+1]
+					if (a[1] > lat) != (b[1] > lat) {
+						crossings = append(crossings, a[0]+(lat-a[1])*(b[0]-a[0])/(b[1]-a[1]))
+					}
+				}
+			}
+			sort.Float64s(crossings)
+			for k := 0; k+1 < len(crossings); k += 2 {
+				c0 := max(int(math.Ceil((crossings[k]-g.West)/s)), 0)
+				c1 := min(int(math.Floor((crossings[k+1]-g.West)/s)), g.Cols-1)
+				for col := c0; col <= c1; col++ {
+					inside[row*g.Cols+col] = true
+				}
+			}
+		}
+	}
+	return inside
+}
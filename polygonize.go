@@ -0,0 +1,214 @@
+package elevation
+
+import (
+	"math"
+	"sort"
+)
+
+// a corner shared by up to four cells
+//
+// corner (i, j) is the top left corner of cell (row i, col j)
+type corner struct {
+	i int
+	j int
+}
+
+type cornerEdge struct {
+	from corner
+	to   corner
+}
+
+// trace the outlines of the cells where inside returns true
+//
+// returns a list of polygons, each a list of rings where the first ring is the exterior
+// and the rest are holes. exteriors wind counter clockwise and holes clockwise
+func (g *Grid) Polygonize(inside func(v float64) bool) [][][]Position {
+	in := func(row int, col int) bool {
+		v := g.At(row, col)
+		return !math.IsNaN(v) && inside(v)
+	}
+
+	// directed edges with the inside on the left (counter clockwise with north up)
+	outgoing := map[corner][]cornerEdge{}
+	add := func(from corner, to corner) {
+		outgoing[from] = append(outgoing[from], cornerEdge{from, to})
+	}
+	for row := range g.Rows {
+		for col := range g.Cols {
+			if !in(row, col) {
+				continue
+			}
+			tl, tr := corner{row, col}, corner{row, col + 1}
+			bl, br := corner{row + 1, col}, corner{row + 1, col + 1}
+			if !in(row+1, col) {
+				add(bl, br)
+			}
+			if !in(row, col+1) {
+				add(br, tr)
+			}
+			if !in(row-1, col) {
+				add(tr, tl)
+			}
+			if !in(row, col-1) {
+				add(tl, bl)
+			}
+		}
+	}
+
+	rings := [][]corner{}
+	for {
+		var start *cornerEdge
+		for _, edges := range outgoing {
+			if len(edges) > 0 {
+				start = &edges[0]
+				break
+			}
+		}
+		if start == nil {
+			break
+		}
+		rings = append(rings, traceRing(outgoing, *start))
+	}
+
+	exteriors := [][]corner{}
+	holes := [][]corner{}
+	for _, r := range rings {
+		if ringArea(r) > 0 {
+			exteriors = append(exteriors, r)
+		} else {
+			holes = append(holes, r)
+		}
+	}
+	// smallest first so holes are assigned to the innermost exterior
+	sort.Slice(exteriors, func(a int, b int) bool { return ringArea(exteriors[a]) < ringArea(exteriors[b]) })
+
+	polygons := make([][][]corner, len(exteriors))
+	for i, e := range exteriors {
+		polygons[i] = [][]corner{e}
+	}
+	for _, h := range holes {
+		// the cell to the left of the first edge is inside the polygon that owns the hole
+		x, y := holeTestPoint(h)
+		for i, e := range exteriors {
+			if pointInRing(x, y, e) {
+				polygons[i] = append(polygons[i], h)
+				break
+			}
+		}
+	}
+
+	out := make([][][]Position, len(polygons))
+	for i, p := range polygons {
+		out[i] = make([][]Position, len(p))
+		for j, r := range p {
+			out[i][j] = g.ringPositions(r)
+		}
+	}
+	return out
+}
+
+// follow edges from start until returning to it
+//
+// where two rings touch at a corner the left most turn is taken so that the rings stay separate
+func traceRing(outgoing map[corner][]cornerEdge, start cornerEdge) []corner {
+	ring := []corner{start.from}
+	edge := start
+	for {
+		removeEdge(outgoing, edge)
+		ring = append(ring, edge.to)
+		if edge.to == start.from {
+			return simplifyRing(ring)
+		}
+		candidates := outgoing[edge.to]
+		next := candidates[0]
+		if len(candidates) > 1 {
+			best := math.Inf(1)
+			for _, c := range candidates {
+				if t := turn(edge, c); t < best {
+					best = t
+					next = c
+				}
+			}
+		}
+		edge = next
+	}
+}
+
+// positive for a right turn, 0 for straight, negative for a left turn
+//
+// corner rows increase to the south so the cross product is flipped
+func turn(a cornerEdge, b cornerEdge) float64 {
+	ax, ay := float64(a.to.j-a.from.j), float64(a.from.i-a.to.i)
+	bx, by := float64(b.to.j-b.from.j), float64(b.from.i-b.to.i)
+	return -(ax*by - ay*bx)
+}
+
+func removeEdge(outgoing map[corner][]cornerEdge, e cornerEdge) {
+	edges := outgoing[e.from]
+	for i, c := range edges {
+		if c == e {
+			outgoing[e.from] = append(edges[:i], edges[i+1:]...)
+			break
+		}
+	}
+	if len(outgoing[e.from]) == 0 {
+		delete(outgoing, e.from)
+	}
+}
+
+// drop corners where the ring continues in a straight line
+func simplifyRing(ring []corner) []corner {
+	out := []corner{ring[0]}
+	for k := 1; k < len(ring)-1; k++ {
+		prev, cur, next := out[len(out)-1], ring[k], ring[k+1]
+		if (cur.i-prev.i)*(next.j-cur.j) == (cur.j-prev.j)*(next.i-cur.i) {
+			continue
+		}
+		out = append(out, cur)
+	}
+	out = append(out, ring[len(ring)-1])
+	return out
+}
+
+// signed area in corner units, positive when counter clockwise with north up
+func ringArea(ring []corner) float64 {
+	a := 0.0
+	for k := 0; k < len(ring)-1; k++ {
+		x1, y1 := float64(ring[k].j), -float64(ring[k].i)
+		x2, y2 := float64(ring[k+1].j), -float64(ring[k+1].i)
+		a += x1*y2 - x2*y1
+	}
+	return a / 2
+}
+
+// center of the cell to the left of the first edge of the hole, as (x, y) with y increasing north
+func holeTestPoint(h []corner) (float64, float64) {
+	a, b := h[0], h[1]
+	dx, dy := float64(b.j-a.j), -float64(b.i-a.i)
+	l := math.Hypot(dx, dy)
+	dx, dy = dx/l, dy/l
+	// half a cell along the edge, then half a cell along the left normal
+	x, y := float64(a.j)+dx*0.5, -float64(a.i)+dy*0.5
+	return x - dy*0.5, y + dx*0.5
+}
+
+func pointInRing(x float64, y float64, ring []corner) bool {
+	in := false
+	for k := 0; k < len(ring)-1; k++ {
+		x1, y1 := float64(ring[k].j), -float64(ring[k].i)
+		x2, y2 := float64(ring[k+1].j), -float64(ring[k+1].i)
+		if (y1 > y) != (y2 > y) && x < (x2-x1)*(y-y1)/(y2-y1)+x1 {
+			in = !in
+		}
+	}
+	return in
+}
+
+func (g *Grid) ringPositions(ring []corner) []Position {
+	s := float64(g.Spacing)
+	positions := make([]Position, len(ring))
+	for k, c := range ring {
+		positions[k] = Position{g.West - s/2 + float64(c.j)*s, g.North + s/2 - float64(c.i)*s}
+	}
+	return positions
+}
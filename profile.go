@@ -0,0 +1,77 @@
+package elevation
+
+import "math"
+
+// a point along a route
+//
+// distance is the cumulative distance in meters from the start of the route
+type ProfilePoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Elevation float64 `json:"elevation"`
+	Distance  float64 `json:"distance"`
+}
+
+// resample path so that there is a point at least every interval meters
+//
+// the original vertices are always kept. elevation is left as 0 and is expected to be filled in by the caller
+func Densify(path []Coordinate, interval float64) []ProfilePoint {
+	if len(path) == 0 {
+		return nil
+	}
+	points := []ProfilePoint{{Latitude: path[0].Latitude, Longitude: path[0].Longitude}}
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		d := Haversine(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+		steps := 1
+		if interval > 0 {
+			steps = int(math.Ceil(d / interval))
+			if steps < 1 {
+				steps = 1
+			}
+		}
+		for s := 1; s <= steps; s++ {
+			t := float64(s) / float64(steps)
+			points = append(points, ProfilePoint{
+				Latitude:  a.Latitude + (b.Latitude-a.Latitude)*t,
+				Longitude: a.Longitude + (b.Longitude-a.Longitude)*t,
+				Distance:  total + d*t,
+			})
+		}
+		total += d
+	}
+	return points
+}
+
+// length of path in meters
+func PathLength(path []Coordinate) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += Haversine(path[i-1].Latitude, path[i-1].Longitude, path[i].Latitude, path[i].Longitude)
+	}
+	return total
+}
+
+// sample the grid every interval meters along the path using bilinear interpolation
+//
+// points that fall on voids have an elevation of NaN
+func (g *Grid) Profile(path []Coordinate, interval float64) []ProfilePoint {
+	profile := Densify(path, interval)
+	for i, p := range profile {
+		profile[i].Elevation = g.Interpolate(p.Latitude, p.Longitude)
+	}
+	return profile
+}
+
+// bounding box around the path, buffered by d degrees
+func PathBoundingBox(path []Coordinate, d float64) BoundingBox {
+	bbox := BoundingBox{MinLatitude: 90, MinLongitude: 180, MaxLatitude: -90, MaxLongitude: -180}
+	for _, p := range path {
+		bbox.MinLatitude = math.Min(bbox.MinLatitude, p.Latitude)
+		bbox.MinLongitude = math.Min(bbox.MinLongitude, p.Longitude)
+		bbox.MaxLatitude = math.Max(bbox.MaxLatitude, p.Latitude)
+		bbox.MaxLongitude = math.Max(bbox.MaxLongitude, p.Longitude)
+	}
+	return bbox.Buffer(d)
+}
@@ -0,0 +1,324 @@
+package elevation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// number of vertices along each edge of a quantized mesh tile
+const QuantizedMeshSize = 65
+
+// the largest quantized u, v and height value
+const quantizedMax = 32767
+
+// WGS84 ellipsoid
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+)
+
+// a tile in the geographic (EPSG:4326) TMS tiling scheme used by Cesium terrain
+//
+// level 0 is two tiles, west and east of the antimeridian. y increases to the north
+type GeographicTile struct {
+	Z int
+	X int
+	Y int
+}
+
+func (t GeographicTile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+func (t GeographicTile) Validate() error {
+	if t.Z < 0 || t.Z > 24 {
+		return fmt.Errorf("invalid tile: %s", t)
+	}
+	n := 1 << t.Z
+	if t.X < 0 || t.X >= 2*n || t.Y < 0 || t.Y >= n {
+		return fmt.Errorf("invalid tile: %s", t)
+	}
+	return nil
+}
+
+// outer edges of the tile
+func (t GeographicTile) Bounds() BoundingBox {
+	size := 180 / math.Exp2(float64(t.Z))
+	return BoundingBox{
+		MinLatitude:  -90 + float64(t.Y)*size,
+		MinLongitude: -180 + float64(t.X)*size,
+		MaxLatitude:  -90 + float64(t.Y+1)*size,
+		MaxLongitude: -180 + float64(t.X+1)*size,
+	}
+}
+
+// the lowest zoom where the vertices of a quantized mesh tile are as dense as the grid
+func QuantizedMeshMaxZoom(spacing Spacing) int {
+	return int(math.Ceil(math.Log2(180 / (float64(spacing) * (QuantizedMeshSize - 1)))))
+}
+
+// earth centered earth fixed coordinates of a point on the WGS84 ellipsoid
+func ecef(lat float64, lng float64, height float64) [3]float64 {
+	phi, lambda := toRadians(lat), toRadians(lng)
+	e2 := wgs84F * (2 - wgs84F)
+	n := wgs84A / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	return [3]float64{
+		(n + height) * math.Cos(phi) * math.Cos(lambda),
+		(n + height) * math.Cos(phi) * math.Sin(lambda),
+		(n*(1-e2) + height) * math.Sin(phi),
+	}
+}
+
+func dot3(a [3]float64, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross3(a [3]float64, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func normalize3(a [3]float64) [3]float64 {
+	l := math.Sqrt(dot3(a, a))
+	if l == 0 {
+		return a
+	}
+	return [3]float64{a[0] / l, a[1] / l, a[2] / l}
+}
+
+// the point in ellipsoid scaled space that, when below the horizon, hides every position
+//
+// follows cesium's EllipsoidalOccluder.computeHorizonCullingPoint
+func horizonOcclusionPoint(center [3]float64, positions [][3]float64) [3]float64 {
+	scale := [3]float64{1 / wgs84A, 1 / wgs84A, 1 / wgs84B}
+	direction := normalize3([3]float64{center[0] * scale[0], center[1] * scale[1], center[2] * scale[2]})
+	magnitude := 0.0
+	for _, p := range positions {
+		scaled := [3]float64{p[0] * scale[0], p[1] * scale[1], p[2] * scale[2]}
+		m2 := dot3(scaled, scaled)
+		m := math.Sqrt(m2)
+		d := [3]float64{scaled[0] / m, scaled[1] / m, scaled[2] / m}
+		m2 = math.Max(1, m2)
+		m = math.Max(1, m)
+		cosAlpha := dot3(d, direction)
+		c := cross3(d, direction)
+		sinAlpha := math.Sqrt(dot3(c, c))
+		cosBeta := 1 / m
+		sinBeta := math.Sqrt(m2-1) * cosBeta
+		denominator := cosAlpha*cosBeta - sinAlpha*sinBeta
+		if denominator <= 0 {
+			// the position can never be hidden by the horizon. cesium never culls
+			// a tile with a NaN point
+			return [3]float64{math.NaN(), math.NaN(), math.NaN()}
+		}
+		magnitude = math.Max(magnitude, 1/denominator)
+	}
+	return [3]float64{direction[0] * magnitude, direction[1] * magnitude, direction[2] * magnitude}
+}
+
+// oct encode a unit vector into two bytes
+func octEncode(n [3]float64) [2]uint8 {
+	l := math.Abs(n[0]) + math.Abs(n[1]) + math.Abs(n[2])
+	x, y := n[0]/l, n[1]/l
+	if n[2] < 0 {
+		x, y = (1-math.Abs(y))*signNotZero(x), (1-math.Abs(x))*signNotZero(y)
+	}
+	snorm := func(v float64) uint8 {
+		return uint8(math.Round((math.Max(-1, math.Min(1, v))*0.5 + 0.5) * 255))
+	}
+	return [2]uint8{snorm(x), snorm(y)}
+}
+
+func signNotZero(v float64) float64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+func zigZag(v int) uint16 {
+	return uint16((v << 1) ^ (v >> 63))
+}
+
+// encode the terrain under a tile in the quantized-mesh-1.0 format
+//
+// the tile is a regular QuantizedMeshSize x QuantizedMeshSize lattice sampled from the grid.
+// a nil grid and voids are at 0 m. normals adds the oct encoded vertex normals extension
+func QuantizedMesh(g *Grid, t GeographicTile, normals bool) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	const n = QuantizedMeshSize
+	bounds := t.Bounds()
+	heights := make([]float64, n*n)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for j := range n {
+		lat := bounds.MinLatitude + (bounds.MaxLatitude-bounds.MinLatitude)*float64(j)/(n-1)
+		for i := range n {
+			lng := bounds.MinLongitude + (bounds.MaxLongitude-bounds.MinLongitude)*float64(i)/(n-1)
+			h := 0.0
+			if g != nil {
+				if v := g.Sample(lat, lng, Bilinear); !math.IsNaN(v) {
+					h = v
+				}
+			}
+			heights[j*n+i] = h
+			lo, hi = math.Min(lo, h), math.Max(hi, h)
+		}
+	}
+
+	positions := make([][3]float64, n*n)
+	boxMin := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	boxMax := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for j := range n {
+		lat := bounds.MinLatitude + (bounds.MaxLatitude-bounds.MinLatitude)*float64(j)/(n-1)
+		for i := range n {
+			lng := bounds.MinLongitude + (bounds.MaxLongitude-bounds.MinLongitude)*float64(i)/(n-1)
+			p := ecef(lat, lng, heights[j*n+i])
+			positions[j*n+i] = p
+			for k := range 3 {
+				boxMin[k] = math.Min(boxMin[k], p[k])
+				boxMax[k] = math.Max(boxMax[k], p[k])
+			}
+		}
+	}
+	sphere := [3]float64{(boxMin[0] + boxMax[0]) / 2, (boxMin[1] + boxMax[1]) / 2, (boxMin[2] + boxMax[2]) / 2}
+	radius := 0.0
+	for _, p := range positions {
+		d := [3]float64{p[0] - sphere[0], p[1] - sphere[1], p[2] - sphere[2]}
+		radius = math.Max(radius, math.Sqrt(dot3(d, d)))
+	}
+	center := ecef((bounds.MinLatitude+bounds.MaxLatitude)/2, (bounds.MinLongitude+bounds.MaxLongitude)/2, (lo+hi)/2)
+
+	// lattice triangles, counter clockwise seen from above
+	lattice := make([][3]int, 0, 2*(n-1)*(n-1))
+	for j := range n - 1 {
+		for i := range n - 1 {
+			sw, se, nw, ne := j*n+i, j*n+i+1, (j+1)*n+i, (j+1)*n+i+1
+			lattice = append(lattice, [3]int{sw, se, ne}, [3]int{sw, ne, nw})
+		}
+	}
+	// high water mark encoding needs the vertices numbered in the order they are first used
+	order := make([]int, 0, n*n)
+	index := make([]int, n*n)
+	for k := range index {
+		index[k] = -1
+	}
+	triangles := make([][3]uint16, len(lattice))
+	for t, tri := range lattice {
+		for k, v := range tri {
+			if index[v] < 0 {
+				index[v] = len(order)
+				order = append(order, v)
+			}
+			triangles[t][k] = uint16(index[v])
+		}
+	}
+
+	var buf bytes.Buffer
+	write := func(v any) { binary.Write(&buf, binary.LittleEndian, v) }
+
+	// header
+	write(center)
+	write([2]float32{float32(lo), float32(hi)})
+	write(sphere)
+	write(radius)
+	write(horizonOcclusionPoint(center, positions))
+
+	// vertices, zig zag delta encoded
+	write(uint32(len(order)))
+	quantize := func(v float64) int { return int(math.Round(v * quantizedMax)) }
+	for _, axis := range []func(v int) int{
+		func(v int) int { return quantize(float64(v%n) / (n - 1)) },
+		func(v int) int { return quantize(float64(v/n) / (n - 1)) },
+		func(v int) int {
+			if hi == lo {
+				return 0
+			}
+			return quantize((heights[v] - lo) / (hi - lo))
+		},
+	} {
+		prev := 0
+		for _, v := range order {
+			q := axis(v)
+			write(zigZag(q - prev))
+			prev = q
+		}
+	}
+
+	// triangles, high water mark encoded
+	write(uint32(len(triangles)))
+	highest := uint16(0)
+	for _, tri := range triangles {
+		for _, v := range tri {
+			write(highest - v)
+			if v == highest {
+				highest++
+			}
+		}
+	}
+
+	// edges
+	edge := func(vertex func(k int) int) {
+		write(uint32(n))
+		for k := range n {
+			write(uint16(index[vertex(k)]))
+		}
+	}
+	edge(func(k int) int { return k * n })       // west
+	edge(func(k int) int { return k })           // south
+	edge(func(k int) int { return k*n + n - 1 }) // east
+	edge(func(k int) int { return (n-1)*n + k }) // north
+
+	if normals {
+		sums := make([][3]float64, n*n)
+		for _, tri := range lattice {
+			a, b, c := positions[tri[0]], positions[tri[1]], positions[tri[2]]
+			face := cross3([3]float64{b[0] - a[0], b[1] - a[1], b[2] - a[2]}, [3]float64{c[0] - a[0], c[1] - a[1], c[2] - a[2]})
+			for _, v := range tri {
+				for k := range 3 {
+					sums[v][k] += face[k]
+				}
+			}
+		}
+		write(uint8(1))
+		write(uint32(2 * len(order)))
+		for _, v := range order {
+			write(octEncode(normalize3(sums[v])))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// the layer.json describing a quantized mesh tileset covering the world
+//
+// every tile up to maxZoom is available
+func QuantizedMeshLayer(maxZoom int, tiles string, extensions []string) map[string]any {
+	available := make([][]map[string]int, maxZoom+1)
+	for z := range available {
+		available[z] = []map[string]int{{
+			"startX": 0, "startY": 0,
+			"endX": (2 << z) - 1, "endY": (1 << z) - 1,
+		}}
+	}
+	if extensions == nil {
+		extensions = []string{}
+	}
+	return map[string]any{
+		"tilejson":    "2.1.0",
+		"name":        "elevation",
+		"version":     "1.0.0",
+		"format":      "quantized-mesh-1.0",
+		"scheme":      "tms",
+		"projection":  "EPSG:4326",
+		"tiles":       []string{tiles},
+		"bounds":      []float64{-180, -90, 180, 90},
+		"minzoom":     0,
+		"maxzoom":     maxZoom,
+		"available":   available,
+		"extensions":  extensions,
+		"attribution": "",
+	}
+}
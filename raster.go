@@ -0,0 +1,139 @@
+package elevation
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// value written for voids in raster outputs
+const NoData = -32768
+
+// write the grid to w as an ESRI ASCII grid (.asc)
+func GridToASCII(w io.Writer, g *Grid) error {
+	bw := bufio.NewWriter(w)
+	s := float64(g.Spacing)
+	_, err := fmt.Fprintf(bw, "ncols %d\nnrows %d\nxllcenter %.10f\nyllcenter %.10f\ncellsize %.10f\nNODATA_value %d\n",
+		g.Cols, g.Rows, g.West, g.Latitude(g.Rows-1), s, NoData)
+	if err != nil {
+		return err
+	}
+	for row := range g.Rows {
+		for col := range g.Cols {
+			if col > 0 {
+				bw.WriteByte(' ')
+			}
+			v := g.At(row, col)
+			if math.IsNaN(v) {
+				v = NoData
+			}
+			bw.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// read an ESRI ASCII grid (.asc) in EPSG:4326
+//
+// both the corner and center forms of the origin are accepted. NODATA_value cells are voids
+func ReadASCIIGrid(r io.Reader) (*Grid, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(bufio.ScanWords)
+
+	header := map[string]float64{}
+	var first string
+	for scanner.Scan() {
+		key := strings.ToLower(scanner.Text())
+		if _, err := strconv.ParseFloat(key, 64); err == nil {
+			first = key
+			break
+		}
+		if !scanner.Scan() {
+			break
+		}
+		v, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header value for %s: %v", key, err)
+		}
+		header[key] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{"ncols", "nrows", "cellsize"} {
+		if _, ok := header[key]; !ok {
+			return nil, fmt.Errorf("missing %s in header", key)
+		}
+	}
+	cols, rows, size := int(header["ncols"]), int(header["nrows"]), header["cellsize"]
+	var west, south float64
+	if v, ok := header["xllcenter"]; ok {
+		west = v
+	} else if v, ok := header["xllcorner"]; ok {
+		west = v + size/2
+	} else {
+		return nil, fmt.Errorf("missing xllcorner or xllcenter in header")
+	}
+	if v, ok := header["yllcenter"]; ok {
+		south = v
+	} else if v, ok := header["yllcorner"]; ok {
+		south = v + size/2
+	} else {
+		return nil, fmt.Errorf("missing yllcorner or yllcenter in header")
+	}
+	noData, hasNoData := header["nodata_value"]
+
+	if rows < 1 || cols < 1 {
+		return nil, fmt.Errorf("invalid size: %dx%d", cols, rows)
+	}
+	values := make([]float64, 0, rows*cols)
+	tok, ok := first, first != ""
+	for ok && len(values) < rows*cols {
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %v", tok, err)
+		}
+		if hasNoData && v == noData {
+			v = math.NaN()
+		}
+		values = append(values, v)
+		if ok = scanner.Scan(); ok {
+			tok = scanner.Text()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) != rows*cols {
+		return nil, fmt.Errorf("expected %d values, got %d", rows*cols, len(values))
+	}
+	return newGridFromRaster(south+float64(rows-1)*size, west, size, size, cols, rows, values)
+}
+
+// write the grid to w as an 8 bit grayscale png
+//
+// values are clamped to 0-255 and voids are written as 0
+func GridToGrayPNG(w io.Writer, g *Grid) error {
+	img := image.NewGray(image.Rect(0, 0, g.Cols, g.Rows))
+	for row := range g.Rows {
+		for col := range g.Cols {
+			v := g.At(row, col)
+			if math.IsNaN(v) {
+				v = 0
+			}
+			img.SetGray(col, row, color.Gray{Y: uint8(math.Max(0, math.Min(255, math.Round(v))))})
+		}
+	}
+	return png.Encode(w, img)
+}
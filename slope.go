@@ -0,0 +1,106 @@
+package elevation
+
+import "math"
+
+// algorithm used to estimate the surface gradient
+type SlopeAlgorithm string
+
+// algorithms used to estimate the surface gradient
+const (
+	// 3rd order finite difference using all 8 neighbors (used by ArcGIS and gdaldem)
+	Horn SlopeAlgorithm = "horn"
+	// 2nd order finite difference using the 4 direct neighbors. better suited to smooth terrain
+	ZevenbergenThorne SlopeAlgorithm = "zevenbergen-thorne"
+)
+
+// aspect reported for flat cells
+const FlatAspect = -1.0
+
+// slope and aspect at a point
+//
+// aspect is the compass direction (degrees clockwise from north) the slope faces
+type SlopeAspect struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	SlopeDegrees float64 `json:"slope_degrees"`
+	SlopePercent float64 `json:"slope_percent"`
+	Aspect       float64 `json:"aspect"`
+}
+
+// partial derivatives (dz/dx, dz/dy) at row,col
+//
+// dz/dx increases to the east and dz/dy increases to the south.
+// returns false if the cell is on the edge or touches a void
+func (g *Grid) Gradient(row int, col int, alg SlopeAlgorithm) (float64, float64, bool) {
+	dx, dy := g.CellSize(row)
+	a, b, c := g.At(row-1, col-1), g.At(row-1, col), g.At(row-1, col+1)
+	d, e, f := g.At(row, col-1), g.At(row, col), g.At(row, col+1)
+	gg, h, i := g.At(row+1, col-1), g.At(row+1, col), g.At(row+1, col+1)
+
+	var dzdx, dzdy float64
+	switch alg {
+	case ZevenbergenThorne:
+		dzdx = (f - d) / (2 * dx)
+		dzdy = (h - b) / (2 * dy)
+	default:
+		dzdx = ((c + 2*f + i) - (a + 2*d + gg)) / (8 * dx)
+		dzdy = ((gg + 2*h + i) - (a + 2*b + c)) / (8 * dy)
+	}
+	if math.IsNaN(dzdx) || math.IsNaN(dzdy) || math.IsNaN(e) {
+		return 0, 0, false
+	}
+	return dzdx, dzdy, true
+}
+
+// slope in degrees and aspect at row,col
+//
+// returns false if the gradient cannot be computed
+func (g *Grid) SlopeAspect(row int, col int, alg SlopeAlgorithm) (float64, float64, bool) {
+	dzdx, dzdy, ok := g.Gradient(row, col, alg)
+	if !ok {
+		return 0, 0, false
+	}
+	return slopeDegrees(dzdx, dzdy), aspectDegrees(dzdx, dzdy), true
+}
+
+func slopeDegrees(dzdx float64, dzdy float64) float64 {
+	return toDegrees(math.Atan(math.Hypot(dzdx, dzdy)))
+}
+
+// convert the gradient to a compass aspect
+func aspectDegrees(dzdx float64, dzdy float64) float64 {
+	if dzdx == 0 && dzdy == 0 {
+		return FlatAspect
+	}
+	// the surface faces down the gradient. with dz/dy increasing to the south,
+	// the downslope vector in (east, north) is (-dz/dx, dz/dy)
+	aspect := toDegrees(math.Atan2(-dzdx, dzdy))
+	if aspect < 0 {
+		aspect += 360
+	}
+	return aspect
+}
+
+// convert a slope in degrees to percent rise
+func SlopePercent(degrees float64) float64 {
+	return math.Tan(toRadians(degrees)) * 100
+}
+
+// compute slope (degrees) and aspect rasters for the whole grid
+//
+// edge cells and cells touching voids are left as voids
+func (g *Grid) Slope(alg SlopeAlgorithm) (*Grid, *Grid) {
+	slope := g.Like()
+	aspect := g.Like()
+	for row := range g.Rows {
+		for col := range g.Cols {
+			s, a, ok := g.SlopeAspect(row, col, alg)
+			if !ok {
+				continue
+			}
+			slope.Set(row, col, s)
+			aspect.Set(row, col, a)
+		}
+	}
+	return slope, aspect
+}
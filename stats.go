@@ -0,0 +1,162 @@
+package elevation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// summary statistics of the values in a grid
+type Statistics struct {
+	Count  int     `json:"count"`
+	Voids  int     `json:"voids"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+}
+
+// compute statistics for every non void cell
+func (g *Grid) Statistics() Statistics {
+	s := Statistics{Min: math.Inf(1), Max: math.Inf(-1)}
+	sum, sumSq := 0.0, 0.0
+	for _, v := range g.Data {
+		if math.IsNaN(v) {
+			s.Voids++
+			continue
+		}
+		s.add(v)
+		sum += v
+		sumSq += v * v
+	}
+	s.finish(sum, sumSq)
+	return s
+}
+
+func (s *Statistics) add(v float64) {
+	s.Count++
+	s.Min = math.Min(s.Min, v)
+	s.Max = math.Max(s.Max, v)
+}
+
+func (s *Statistics) finish(sum float64, sumSq float64) {
+	if s.Count == 0 {
+		s.Min, s.Max = 0, 0
+		return
+	}
+	s.Mean = sum / float64(s.Count)
+	s.StdDev = math.Sqrt(math.Max(0, sumSq/float64(s.Count)-s.Mean*s.Mean))
+}
+
+// percentiles reported when none are requested
+var DefaultPercentiles = []float64{5, 10, 25, 75, 90, 95}
+
+// one elevation band of a hypsometric histogram
+type HistogramBin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+	// area (m²) of the cells in the band
+	Area float64 `json:"area"`
+	// percentage of the total area at or above the bottom of the band
+	PercentAbove float64 `json:"percent_above"`
+}
+
+// statistics of the cells inside of a polygon
+type ZonalStatistics struct {
+	Statistics
+	// percentage of the cells inside of the polygon that are void
+	VoidPercent float64 `json:"void_percent"`
+	Median      float64 `json:"median"`
+	// keyed by percentile, e.g. p25
+	Percentiles map[string]float64 `json:"percentiles"`
+	// area (m²) of the cells with data
+	Area      float64        `json:"area"`
+	Histogram []HistogramBin `json:"histogram"`
+}
+
+// compute statistics for the cells where inside is true
+//
+// percentiles are linearly interpolated between the sorted cell values. the histogram splits
+// the range into bins equal bands, weighting each cell by its area
+func (g *Grid) ZonalStatistics(inside []bool, percentiles []float64, bins int) (ZonalStatistics, error) {
+	if bins < 1 {
+		return ZonalStatistics{}, fmt.Errorf("invalid number of bins: %d", bins)
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return ZonalStatistics{}, fmt.Errorf("invalid percentile: %g", p)
+		}
+	}
+
+	z := ZonalStatistics{Statistics: Statistics{Min: math.Inf(1), Max: math.Inf(-1)}}
+	values := []float64{}
+	areas := []float64{}
+	sum, sumSq := 0.0, 0.0
+	for row := range g.Rows {
+		dx, dy := g.CellSize(row)
+		for col := range g.Cols {
+			if !inside[row*g.Cols+col] {
+				continue
+			}
+			v := g.At(row, col)
+			if math.IsNaN(v) {
+				z.Voids++
+				continue
+			}
+			z.add(v)
+			sum += v
+			sumSq += v * v
+			z.Area += dx * dy
+			values = append(values, v)
+			areas = append(areas, dx*dy)
+		}
+	}
+	z.finish(sum, sumSq)
+	if z.Count+z.Voids == 0 {
+		return ZonalStatistics{}, fmt.Errorf("no cells inside of the polygon")
+	}
+	z.VoidPercent = 100 * float64(z.Voids) / float64(z.Count+z.Voids)
+	z.Percentiles = map[string]float64{}
+	z.Histogram = []HistogramBin{}
+	if z.Count == 0 {
+		return z, nil
+	}
+
+	width := (z.Max - z.Min) / float64(bins)
+	if width == 0 {
+		bins, width = 1, 1
+	}
+	z.Histogram = make([]HistogramBin, bins)
+	for i := range z.Histogram {
+		z.Histogram[i].Min = z.Min + float64(i)*width
+		z.Histogram[i].Max = z.Min + float64(i+1)*width
+	}
+	for i, v := range values {
+		b := min(int((v-z.Min)/width), bins-1)
+		z.Histogram[b].Count++
+		z.Histogram[b].Area += areas[i]
+	}
+	above := 0.0
+	for i := bins - 1; i >= 0; i-- {
+		above += z.Histogram[i].Area
+		z.Histogram[i].PercentAbove = 100 * above / z.Area
+	}
+
+	sort.Float64s(values)
+	z.Median = percentile(values, 50)
+	for _, p := range percentiles {
+		z.Percentiles[fmt.Sprintf("p%g", p)] = percentile(values, p)
+	}
+	return z, nil
+}
+
+// p-th percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	f := p / 100 * float64(len(sorted)-1)
+	i := int(f)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (f-float64(i))*(sorted[i+1]-sorted[i])
+}
@@ -0,0 +1,217 @@
+package elevation
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// values of a shadow grid
+const (
+	Shaded = 0.0
+	Sunlit = 1.0
+)
+
+// position of the sun in the sky
+type SolarPosition struct {
+	// degrees clockwise from north
+	Azimuth float64 `json:"azimuth"`
+	// degrees above the astronomical horizon, corrected for atmospheric refraction
+	Elevation float64 `json:"elevation"`
+}
+
+// position of the sun seen from lat,lng at t using the NOAA solar calculator equations
+func SunPosition(t time.Time, lat float64, lng float64) SolarPosition {
+	t = t.UTC()
+	jd := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+	c := (jd - 2451545) / 36525
+
+	meanLng := math.Mod(280.46646+c*(36000.76983+c*0.0003032), 360)
+	meanAnomaly := toRadians(357.52911 + c*(35999.05029-0.0001537*c))
+	ecc := 0.016708634 - c*(0.000042037+0.0000001267*c)
+	center := math.Sin(meanAnomaly)*(1.914602-c*(0.004817+0.000014*c)) +
+		math.Sin(2*meanAnomaly)*(0.019993-0.000101*c) +
+		math.Sin(3*meanAnomaly)*0.000289
+	omega := toRadians(125.04 - 1934.136*c)
+	apparentLng := toRadians(meanLng + center - 0.00569 - 0.00478*math.Sin(omega))
+	obliquity := toRadians(23 + (26+(21.448-c*(46.815+c*(0.00059-c*0.001813)))/60)/60 + 0.00256*math.Cos(omega))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(apparentLng))
+
+	// equation of time in minutes
+	y := math.Pow(math.Tan(obliquity/2), 2)
+	l0 := toRadians(meanLng)
+	eqTime := 4 * toDegrees(y*math.Sin(2*l0)-
+		2*ecc*math.Sin(meanAnomaly)+
+		4*ecc*y*math.Sin(meanAnomaly)*math.Cos(2*l0)-
+		0.5*y*y*math.Sin(4*l0)-
+		1.25*ecc*ecc*math.Sin(2*meanAnomaly))
+
+	minutes := float64(t.Hour()*60+t.Minute()) + (float64(t.Second())+float64(t.Nanosecond())/1e9)/60
+	hourAngle := toRadians((minutes+eqTime+4*lng)/4 - 180)
+	phi := toRadians(lat)
+
+	elevation := toDegrees(math.Asin(math.Sin(phi)*math.Sin(declination) + math.Cos(phi)*math.Cos(declination)*math.Cos(hourAngle)))
+	azimuth := toDegrees(math.Atan2(math.Sin(hourAngle), math.Cos(hourAngle)*math.Sin(phi)-math.Tan(declination)*math.Cos(phi))) + 180
+
+	// Sæmundsson's formula, in arc minutes
+	if elevation > -1 {
+		elevation += 1.02 / math.Tan(toRadians(elevation+10.3/(elevation+5.11))) / 60
+	}
+	return SolarPosition{Azimuth: math.Mod(azimuth, 360), Elevation: elevation}
+}
+
+// whether a point is lit by the sun at an instant
+type SunExposure struct {
+	Time time.Time     `json:"time"`
+	Sun  SolarPosition `json:"sun"`
+	// the terrain horizon in the direction of the sun
+	Horizon HorizonPoint `json:"horizon"`
+	Sunlit  bool         `json:"sunlit"`
+}
+
+// check if point is in the shadow of the terrain at t
+func (g *Grid) SunExposure(point Coordinate, t time.Time, opts HorizonOptions) (SunExposure, error) {
+	ground := g.Interpolate(point.Latitude, point.Longitude)
+	if math.IsNaN(ground) {
+		return SunExposure{}, fmt.Errorf("no data at %f, %f", point.Latitude, point.Longitude)
+	}
+	sun := SunPosition(t, point.Latitude, point.Longitude)
+	horizon := g.HorizonAt(point, ground+opts.EyeHeight, sun.Azimuth, opts)
+	return SunExposure{
+		Time:    t.UTC(),
+		Sun:     sun,
+		Horizon: horizon,
+		Sunlit:  sun.Elevation > math.Max(horizon.Angle, 0),
+	}, nil
+}
+
+// elevation angle of the horizon at azimuth, interpolated between the nearest points
+//
+// terrain beyond the search radius is treated as flat
+func (h Horizon) AngleAt(azimuth float64) float64 {
+	n := len(h.Points)
+	if n == 0 {
+		return 0
+	}
+	step := 360 / float64(n)
+	f := math.Mod(azimuth+360, 360) / step
+	i := int(f) % n
+	frac := f - math.Floor(f)
+	a, b := h.Points[i], h.Points[(i+1)%n]
+	if a.Distance == 0 || b.Distance == 0 {
+		return 0
+	}
+	return a.Angle*(1-frac) + b.Angle*frac
+}
+
+// hours of direct sunlight at a point over a day
+type SunHours struct {
+	Date string `json:"date"`
+	// hours the sun is above the astronomical horizon
+	DayLength float64 `json:"day_length"`
+	// hours the sun is above the terrain
+	Hours float64 `json:"hours"`
+	// first and last time the sun clears the terrain
+	Sunrise *time.Time `json:"sunrise,omitempty"`
+	Sunset  *time.Time `json:"sunset,omitempty"`
+}
+
+// sample the sun every interval over the solar day of date and compare it to the horizon
+//
+// the day runs from local mean solar midnight so that it is not split at the UTC date line
+func (h Horizon) SunHours(date time.Time, interval time.Duration) SunHours {
+	lat, lng := h.Observer.Latitude, h.Observer.Longitude
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	start := day.Add(-time.Duration(lng / 15 * float64(time.Hour)))
+
+	out := SunHours{Date: day.Format(time.DateOnly)}
+	hours := interval.Hours()
+	for t := start; t.Before(start.Add(24 * time.Hour)); t = t.Add(interval) {
+		sun := SunPosition(t, lat, lng)
+		if sun.Elevation <= 0 {
+			continue
+		}
+		out.DayLength += hours
+		if sun.Elevation <= h.AngleAt(sun.Azimuth) {
+			continue
+		}
+		out.Hours += hours
+		if out.Sunrise == nil {
+			out.Sunrise = &t
+		}
+		out.Sunset = &t
+	}
+	return out
+}
+
+// cast shadows over the grid for the sun at t
+//
+// the sun position is computed once at the center of the grid. shadows are traced up to
+// radius meters towards the sun, so the grid should extend that far past the area of interest.
+// every cell is Shaded when the sun is below the horizon
+func (g *Grid) Shadow(t time.Time, radius float64) *Grid {
+	bbox := g.BoundingBox()
+	sun := SunPosition(t, (bbox.MinLatitude+bbox.MaxLatitude)/2, (bbox.MinLongitude+bbox.MaxLongitude)/2)
+
+	out := g.Like()
+	for i, v := range g.Data {
+		if !math.IsNaN(v) {
+			out.Data[i] = Shaded
+		}
+	}
+	if sun.Elevation <= 0 {
+		return out
+	}
+
+	_, hi := g.MinMax()
+	rise := math.Tan(toRadians(sun.Elevation))
+	sinA, cosA := math.Sin(toRadians(sun.Azimuth)), math.Cos(toRadians(sun.Azimuth))
+	for row := range g.Rows {
+		dx, dy := g.CellSize(row)
+		step := math.Min(dx, dy)
+		for col := range g.Cols {
+			z0 := g.At(row, col)
+			if math.IsNaN(z0) {
+				continue
+			}
+			lit := true
+			for d := step; d <= radius; d += step {
+				ray := z0 + d*rise
+				if ray > hi {
+					break
+				}
+				r := row - int(math.Round(d*cosA/dy))
+				c := col + int(math.Round(d*sinA/dx))
+				if !g.InBounds(r, c) {
+					break
+				}
+				if g.At(r, c) > ray {
+					lit = false
+					break
+				}
+			}
+			if lit {
+				out.Set(row, col, Sunlit)
+			}
+		}
+	}
+	return out
+}
+
+// convert a shadow grid to GeoJSON polygons of the shaded area
+func ShadowToGeoJSON(shadow *Grid) FeatureCollection {
+	polygons := shadow.Polygonize(func(v float64) bool { return v == Shaded })
+	features := make([]Feature, len(polygons))
+	for i, p := range polygons {
+		features[i] = NewFeature(NewPolygon(p), map[string]any{"shaded": true})
+	}
+	return NewFeatureCollection(features)
+}
+
+// write the shadow grid to w as a png
+//
+// sunlit cells are white, shaded cells are black, and voids are transparent
+func ShadowToPNG(w io.Writer, shadow *Grid) error {
+	return maskToPNG(w, shadow)
+}
@@ -0,0 +1,153 @@
+package elevation
+
+import "math"
+
+// a terrain index that can be computed for every cell of a grid
+type TerrainIndex string
+
+const (
+	// Terrain Ruggedness Index (Riley et al. 1999)
+	TRI TerrainIndex = "tri"
+	// Topographic Position Index
+	TPI TerrainIndex = "tpi"
+	// largest difference between any two cells in the 3x3 window
+	Roughness TerrainIndex = "roughness"
+	// curvature in the direction of the slope. negative values are convex
+	ProfileCurvature TerrainIndex = "profile-curvature"
+	// curvature perpendicular to the slope. positive values are convex
+	PlanCurvature TerrainIndex = "plan-curvature"
+)
+
+// parameters for terrain indexes
+type TerrainOptions struct {
+	// inner and outer radius (cells) of the TPI neighborhood annulus
+	InnerRadius int
+	OuterRadius int
+}
+
+var DefaultTerrainOptions = TerrainOptions{InnerRadius: 0, OuterRadius: 3}
+
+// the 3x3 window around row,col
+//
+// returns false if any of the cells are voids
+func (g *Grid) window(row int, col int) ([9]float64, bool) {
+	w := [9]float64{}
+	k := 0
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			v := g.At(row+dr, col+dc)
+			if math.IsNaN(v) {
+				return w, false
+			}
+			w[k] = v
+			k++
+		}
+	}
+	return w, true
+}
+
+// compute index for every cell
+//
+// cells whose neighborhood is incomplete are left as voids
+func (g *Grid) TerrainIndex(index TerrainIndex, opts TerrainOptions) *Grid {
+	switch index {
+	case TPI:
+		return g.tpi(opts.InnerRadius, opts.OuterRadius)
+	case ProfileCurvature, PlanCurvature:
+		return g.curvature(index == ProfileCurvature)
+	}
+	out := g.Like()
+	for row := range g.Rows {
+		for col := range g.Cols {
+			w, ok := g.window(row, col)
+			if !ok {
+				continue
+			}
+			switch index {
+			case TRI:
+				sum := 0.0
+				for _, v := range w {
+					sum += (v - w[4]) * (v - w[4])
+				}
+				out.Set(row, col, math.Sqrt(sum))
+			case Roughness:
+				lo, hi := w[0], w[0]
+				for _, v := range w {
+					lo, hi = math.Min(lo, v), math.Max(hi, v)
+				}
+				out.Set(row, col, hi-lo)
+			}
+		}
+	}
+	return out
+}
+
+// elevation of each cell minus the mean of the annulus inner < d <= outer cells around it
+func (g *Grid) tpi(inner int, outer int) *Grid {
+	type offset struct{ dr, dc int }
+	offsets := []offset{}
+	for dr := -outer; dr <= outer; dr++ {
+		for dc := -outer; dc <= outer; dc++ {
+			d := math.Hypot(float64(dr), float64(dc))
+			if d > float64(inner) && d <= float64(outer) {
+				offsets = append(offsets, offset{dr, dc})
+			}
+		}
+	}
+	out := g.Like()
+	for row := range g.Rows {
+		for col := range g.Cols {
+			z := g.At(row, col)
+			if math.IsNaN(z) {
+				continue
+			}
+			sum, n := 0.0, 0
+			for _, o := range offsets {
+				v := g.At(row+o.dr, col+o.dc)
+				if math.IsNaN(v) {
+					n = 0
+					break
+				}
+				sum += v
+				n++
+			}
+			if n > 0 {
+				out.Set(row, col, z-sum/float64(n))
+			}
+		}
+	}
+	return out
+}
+
+// profile or plan curvature (1/m) using the Zevenbergen-Thorne polynomial
+func (g *Grid) curvature(profile bool) *Grid {
+	out := g.Like()
+	for row := range g.Rows {
+		dx, dy := g.CellSize(row)
+		for col := range g.Cols {
+			w, ok := g.window(row, col)
+			if !ok {
+				continue
+			}
+			a, b, c := w[0], w[1], w[2]
+			d, e, f := w[3], w[4], w[5]
+			gg, h, i := w[6], w[7], w[8]
+			D := ((d+f)/2 - e) / (dx * dx)
+			E := ((b+h)/2 - e) / (dy * dy)
+			F := (-a + c + gg - i) / (4 * dx * dy)
+			G := (f - d) / (2 * dx)
+			H := (b - h) / (2 * dy)
+			denom := G*G + H*H
+			if denom == 0 {
+				out.Set(row, col, 0)
+				continue
+			}
+			if profile {
+				out.Set(row, col, -2*(D*G*G+E*H*H+F*G*H)/denom)
+			} else {
+				out.Set(row, col, 2*(D*H*H+E*G*G-F*G*H)/denom)
+			}
+		}
+	}
+	return out
+}
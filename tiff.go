@@ -0,0 +1,395 @@
+package elevation
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// more tiff field types
+const (
+	tiffByte     = 1
+	tiffRational = 5
+	tiffSByte    = 6
+	tiffSShort   = 8
+	tiffSLong    = 9
+	tiffFloat    = 11
+)
+
+// tiff compression schemes that can be decoded
+const (
+	tiffUncompressed = 1
+	tiffLZW          = 5
+	tiffDeflate      = 8
+	tiffAdobeDeflate = 32946
+)
+
+// a field of the first ifd of a tiff
+type tiffField struct {
+	typ   uint16
+	count int
+	data  []byte
+}
+
+// the first image of a tiff file
+type tiffImage struct {
+	order  binary.ByteOrder
+	data   []byte
+	fields map[uint16]tiffField
+}
+
+func tiffTypeSize(typ uint16) int {
+	switch typ {
+	case tiffByte, tiffASCII, tiffSByte, 7:
+		return 1
+	case tiffShort, tiffSShort:
+		return 2
+	case tiffLong, tiffSLong, tiffFloat:
+		return 4
+	case tiffRational, 10, tiffDouble:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// parse the header and first ifd
+//
+// BigTIFF is not supported
+func parseTIFF(data []byte) (*tiffImage, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("not a tiff")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a tiff")
+	}
+	switch order.Uint16(data[2:]) {
+	case 42:
+	case 43:
+		return nil, fmt.Errorf("unsupported tiff: BigTIFF")
+	default:
+		return nil, fmt.Errorf("not a tiff")
+	}
+	ifd := int(order.Uint32(data[4:]))
+	if ifd+2 > len(data) {
+		return nil, fmt.Errorf("invalid tiff: ifd out of range")
+	}
+	n := int(order.Uint16(data[ifd:]))
+	if ifd+2+12*n > len(data) {
+		return nil, fmt.Errorf("invalid tiff: ifd out of range")
+	}
+	img := &tiffImage{order: order, data: data, fields: map[uint16]tiffField{}}
+	for i := range n {
+		e := data[ifd+2+12*i : ifd+14+12*i]
+		f := tiffField{typ: order.Uint16(e[2:]), count: int(order.Uint32(e[4:]))}
+		size := tiffTypeSize(f.typ) * f.count
+		if size <= 4 {
+			f.data = e[8 : 8+size]
+		} else {
+			offset := int(order.Uint32(e[8:]))
+			if offset < 0 || offset+size > len(data) {
+				return nil, fmt.Errorf("invalid tiff: tag %d out of range", order.Uint16(e))
+			}
+			f.data = data[offset : offset+size]
+		}
+		img.fields[order.Uint16(e)] = f
+	}
+	return img, nil
+}
+
+// numeric values of a field, nil if missing
+func (t *tiffImage) floats(tag uint16) []float64 {
+	f, ok := t.fields[tag]
+	if !ok {
+		return nil
+	}
+	size := tiffTypeSize(f.typ)
+	out := make([]float64, 0, f.count)
+	for i := range f.count {
+		b := f.data[i*size:]
+		switch f.typ {
+		case tiffByte:
+			out = append(out, float64(b[0]))
+		case tiffSByte:
+			out = append(out, float64(int8(b[0])))
+		case tiffShort:
+			out = append(out, float64(t.order.Uint16(b)))
+		case tiffSShort:
+			out = append(out, float64(int16(t.order.Uint16(b))))
+		case tiffLong:
+			out = append(out, float64(t.order.Uint32(b)))
+		case tiffSLong:
+			out = append(out, float64(int32(t.order.Uint32(b))))
+		case tiffRational:
+			out = append(out, float64(t.order.Uint32(b))/float64(t.order.Uint32(b[4:])))
+		case tiffFloat:
+			out = append(out, float64(math.Float32frombits(t.order.Uint32(b))))
+		case tiffDouble:
+			out = append(out, math.Float64frombits(t.order.Uint64(b)))
+		default:
+			return nil
+		}
+	}
+	return out
+}
+
+// first value of a numeric field, def if missing
+func (t *tiffImage) number(tag uint16, def int) int {
+	if v := t.floats(tag); len(v) > 0 {
+		return int(v[0])
+	}
+	return def
+}
+
+func (t *tiffImage) ascii(tag uint16) (string, bool) {
+	f, ok := t.fields[tag]
+	if !ok || f.typ != tiffASCII {
+		return "", false
+	}
+	return strings.TrimRight(string(f.data), "\x00"), true
+}
+
+// decode the first band, row by row from the top
+//
+// supports strips and tiles of 8 to 64 bit integers and floats, uncompressed or with LZW
+// or deflate compression and horizontal or floating point prediction. the GDAL_NODATA
+// value and NaN are returned as NaN
+func (t *tiffImage) decode() (int, int, []float64, error) {
+	width, height := t.number(256, 0), t.number(257, 0)
+	bits, format := t.number(258, 1), t.number(339, 1)
+	samples, planar := t.number(277, 1), t.number(284, 1)
+	compression, predictor := t.number(259, tiffUncompressed), t.number(317, 1)
+	if width < 1 || height < 1 {
+		return 0, 0, nil, fmt.Errorf("invalid tiff: %dx%d", width, height)
+	}
+	if samples != 1 && planar != 2 {
+		return 0, 0, nil, fmt.Errorf("unsupported tiff: %d interleaved bands", samples)
+	}
+	size := bits / 8
+	var sample func(b []byte) float64
+	switch {
+	case format == 3 && bits == 32:
+		sample = func(b []byte) float64 { return float64(math.Float32frombits(t.order.Uint32(b))) }
+	case format == 3 && bits == 64:
+		sample = func(b []byte) float64 { return math.Float64frombits(t.order.Uint64(b)) }
+	case format == 2 && bits == 8:
+		sample = func(b []byte) float64 { return float64(int8(b[0])) }
+	case format == 2 && bits == 16:
+		sample = func(b []byte) float64 { return float64(int16(t.order.Uint16(b))) }
+	case format == 2 && bits == 32:
+		sample = func(b []byte) float64 { return float64(int32(t.order.Uint32(b))) }
+	case format == 1 && bits == 8:
+		sample = func(b []byte) float64 { return float64(b[0]) }
+	case format == 1 && bits == 16:
+		sample = func(b []byte) float64 { return float64(t.order.Uint16(b)) }
+	case format == 1 && bits == 32:
+		sample = func(b []byte) float64 { return float64(t.order.Uint32(b)) }
+	default:
+		return 0, 0, nil, fmt.Errorf("unsupported tiff: %d bit samples of format %d", bits, format)
+	}
+	noData := math.NaN()
+	if s, ok := t.ascii(42113); ok {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			noData = v
+		}
+	}
+
+	// blocks are strips or tiles
+	blockWidth, blockHeight := width, t.number(278, height)
+	offsets, counts := t.floats(273), t.floats(279)
+	if _, tiled := t.fields[322]; tiled {
+		blockWidth, blockHeight = t.number(322, 0), t.number(323, 0)
+		offsets, counts = t.floats(324), t.floats(325)
+	}
+	if blockWidth < 1 || blockHeight < 1 {
+		return 0, 0, nil, fmt.Errorf("invalid tiff: %dx%d blocks", blockWidth, blockHeight)
+	}
+	across := (width + blockWidth - 1) / blockWidth
+	down := (height + blockHeight - 1) / blockHeight
+	// only the first band of planar images is read
+	if len(offsets) < across*down || len(counts) < across*down {
+		return 0, 0, nil, fmt.Errorf("invalid tiff: expected %d blocks, got %d", across*down, len(offsets))
+	}
+
+	out := make([]float64, width*height)
+	rowBytes := blockWidth * size
+	for by := range down {
+		for bx := range across {
+			i := by*across + bx
+			start, n := int(offsets[i]), int(counts[i])
+			if start < 0 || start+n > len(t.data) {
+				return 0, 0, nil, fmt.Errorf("invalid tiff: block %d out of range", i)
+			}
+			block, err := tiffDecompress(t.data[start:start+n], compression, rowBytes*blockHeight)
+			if err != nil {
+				return 0, 0, nil, fmt.Errorf("block %d: %v", i, err)
+			}
+			// strips at the bottom may be short
+			rows := min(blockHeight, len(block)/rowBytes)
+			if err := tiffUnpredict(block[:rows*rowBytes], predictor, rowBytes, size, t.order); err != nil {
+				return 0, 0, nil, err
+			}
+			for y := range rows {
+				row := by*blockHeight + y
+				if row >= height {
+					break
+				}
+				for x := range min(blockWidth, width-bx*blockWidth) {
+					v := sample(block[y*rowBytes+x*size:])
+					if v == noData {
+						v = math.NaN()
+					}
+					out[row*width+bx*blockWidth+x] = v
+				}
+			}
+		}
+	}
+	return width, height, out, nil
+}
+
+func tiffDecompress(data []byte, compression int, expected int) ([]byte, error) {
+	switch compression {
+	case tiffUncompressed:
+		return data, nil
+	case tiffLZW:
+		return tiffLZWDecode(data, expected)
+	case tiffDeflate, tiffAdobeDeflate:
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unsupported tiff compression: %d", compression)
+	}
+}
+
+// decode tiff flavoured lzw: msb first codes with the code width growing one code early
+func tiffLZWDecode(data []byte, expected int) ([]byte, error) {
+	const (
+		clearCode = 256
+		eoiCode   = 257
+	)
+	out := make([]byte, 0, expected)
+	table := make([][]byte, 4096)
+	for i := range 256 {
+		table[i] = []byte{byte(i)}
+	}
+	next, width := 258, 9
+	var buf uint32
+	var bits, pos int
+	read := func() int {
+		for bits < width {
+			if pos >= len(data) {
+				return eoiCode
+			}
+			buf = buf<<8 | uint32(data[pos])
+			pos++
+			bits += 8
+		}
+		bits -= width
+		return int(buf>>bits) & (1<<width - 1)
+	}
+	old := -1
+	for {
+		code := read()
+		if code == eoiCode {
+			break
+		}
+		if code == clearCode {
+			next, width, old = 258, 9, -1
+			continue
+		}
+		var entry []byte
+		switch {
+		case old < 0:
+			if code > 255 {
+				return nil, fmt.Errorf("invalid lzw code: %d", code)
+			}
+			entry = table[code]
+		case code < next:
+			entry = table[code]
+			if next < len(table) {
+				table[next] = append(append([]byte{}, table[old]...), entry[0])
+				next++
+			}
+		case code == next:
+			entry = append(append([]byte{}, table[old]...), table[old][0])
+			if next < len(table) {
+				table[next] = entry
+				next++
+			}
+		default:
+			return nil, fmt.Errorf("invalid lzw code: %d", code)
+		}
+		out = append(out, entry...)
+		old = code
+		if next >= 1<<width-1 && width < 12 {
+			width++
+		}
+	}
+	return out, nil
+}
+
+// undo the tiff predictor in place
+func tiffUnpredict(block []byte, predictor int, rowBytes int, size int, order binary.ByteOrder) error {
+	switch predictor {
+	case 1:
+		return nil
+	case 2:
+		// horizontal differencing of integer samples
+		for y := 0; y+rowBytes <= len(block); y += rowBytes {
+			row := block[y : y+rowBytes]
+			for x := size; x+size <= len(row); x += size {
+				switch size {
+				case 1:
+					row[x] += row[x-1]
+				case 2:
+					order.PutUint16(row[x:], order.Uint16(row[x:])+order.Uint16(row[x-size:]))
+				case 4:
+					order.PutUint32(row[x:], order.Uint32(row[x:])+order.Uint32(row[x-size:]))
+				case 8:
+					order.PutUint64(row[x:], order.Uint64(row[x:])+order.Uint64(row[x-size:]))
+				}
+			}
+		}
+		return nil
+	case 3:
+		// floating point: bytes are differenced, then split into planes from most significant
+		tmp := make([]byte, rowBytes)
+		count := rowBytes / size
+		for y := 0; y+rowBytes <= len(block); y += rowBytes {
+			row := block[y : y+rowBytes]
+			for x := 1; x < rowBytes; x++ {
+				row[x] += row[x-1]
+			}
+			copy(tmp, row)
+			for i := range count {
+				for b := range size {
+					// planes are big endian
+					v := tmp[b*count+i]
+					if order == binary.LittleEndian {
+						row[i*size+size-1-b] = v
+					} else {
+						row[i*size+b] = v
+					}
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported tiff predictor: %d", predictor)
+	}
+}
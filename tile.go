@@ -0,0 +1,246 @@
+package elevation
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// width and height of a map tile in pixels
+const TileSize = 256
+
+// the latitude where web mercator is cut off
+const MaxMercatorLatitude = 85.0511287798
+
+// an XYZ tile in the web mercator (EPSG:3857) tiling scheme
+//
+// y increases to the south
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+func (t Tile) Validate() error {
+	if t.Z < 0 || t.Z > 24 {
+		return fmt.Errorf("invalid tile: %s", t)
+	}
+	n := 1 << t.Z
+	if t.X < 0 || t.X >= n || t.Y < 0 || t.Y >= n {
+		return fmt.Errorf("invalid tile: %s", t)
+	}
+	return nil
+}
+
+// lat,lng of a point in global pixel coordinates at zoom z
+func mercatorToLatLng(z int, px float64, py float64) (float64, float64) {
+	size := float64(TileSize) * math.Exp2(float64(z))
+	lng := px/size*360 - 180
+	lat := toDegrees(math.Atan(math.Sinh(math.Pi * (1 - 2*py/size))))
+	return lat, lng
+}
+
+// tile at zoom z containing lat,lng
+func TileAt(z int, lat float64, lng float64) Tile {
+	n := math.Exp2(float64(z))
+	lat = math.Max(-MaxMercatorLatitude, math.Min(MaxMercatorLatitude, lat))
+	x := int(math.Floor((lng + 180) / 360 * n))
+	y := int(math.Floor((1 - math.Log(math.Tan(toRadians(lat))+1/math.Cos(toRadians(lat)))/math.Pi) / 2 * n))
+	maxIndex := int(n) - 1
+	return Tile{Z: z, X: max(0, min(x, maxIndex)), Y: max(0, min(y, maxIndex))}
+}
+
+// outer edges of the tile
+func (t Tile) Bounds() BoundingBox {
+	north, west := mercatorToLatLng(t.Z, float64(t.X*TileSize), float64(t.Y*TileSize))
+	south, east := mercatorToLatLng(t.Z, float64((t.X+1)*TileSize), float64((t.Y+1)*TileSize))
+	return BoundingBox{MinLatitude: south, MinLongitude: west, MaxLatitude: north, MaxLongitude: east}
+}
+
+// lat,lng of the center of pixel px,py in the tile
+func (t Tile) PixelCenter(px int, py int) (float64, float64) {
+	return mercatorToLatLng(t.Z, float64(t.X*TileSize+px)+0.5, float64(t.Y*TileSize+py)+0.5)
+}
+
+// sample the grid at the center of every pixel of the tile
+//
+// returns TileSize * TileSize elevations, row by row from the north west corner
+func (g *Grid) TileElevations(t Tile, method InterpolationMethod) []float64 {
+	out := make([]float64, TileSize*TileSize)
+	for py := range TileSize {
+		for px := range TileSize {
+			lat, lng := t.PixelCenter(px, py)
+			out[py*TileSize+px] = g.Sample(lat, lng, method)
+		}
+	}
+	return out
+}
+
+// how elevations are packed into the rgb channels of a tile
+type TileEncoding string
+
+const (
+	// Mapbox Terrain-RGB: -10000 + (R * 256 * 256 + G * 256 + B) * 0.1
+	TerrainRGB TileEncoding = "terrain-rgb"
+	// Mapzen Terrarium: (R * 256 + G + B / 256) - 32768
+	Terrarium TileEncoding = "terrarium"
+)
+
+// pack an elevation into rgb. voids are encoded as 0 m
+//
+// neither encoding has a void value and clients decode every pixel, transparent or not, as an
+// elevation. a transparent black pixel would be a -10000 m pit in Terrain-RGB, so voids are
+// drawn as sea level instead
+func (e TileEncoding) Color(elev float64) (color.NRGBA, error) {
+	if math.IsNaN(elev) {
+		elev = 0
+	}
+	switch e {
+	case TerrainRGB:
+		v := uint32(math.Max(0, math.Min(1<<24-1, math.Round((elev+10000)*10))))
+		return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+	case Terrarium:
+		v := math.Max(0, math.Min(65535, elev+32768))
+		whole := math.Floor(v)
+		return color.NRGBA{R: uint8(int(whole) >> 8), G: uint8(int(whole)), B: uint8((v - whole) * 256), A: 255}, nil
+	default:
+		return color.NRGBA{}, fmt.Errorf("invalid tile encoding: %s", e)
+	}
+}
+
+// unpack an elevation from rgb. NaN for transparent pixels
+func (e TileEncoding) Elevation(c color.NRGBA) float64 {
+	if c.A == 0 {
+		return math.NaN()
+	}
+	switch e {
+	case TerrainRGB:
+		return -10000 + float64(uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))*0.1
+	case Terrarium:
+		return float64(uint32(c.R)<<8|uint32(c.G)) + float64(c.B)/256 - 32768
+	default:
+		return math.NaN()
+	}
+}
+
+// average the elevations encoded in colors, ignoring transparent pixels
+//
+// sea level when every pixel is transparent, like a void in Color
+func (e TileEncoding) Average(colors []color.NRGBA) color.NRGBA {
+	sum, n := 0.0, 0
+	for _, c := range colors {
+		if v := e.Elevation(c); !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		c, _ := e.Color(0)
+		return c
+	}
+	c, _ := e.Color(sum / float64(n))
+	return c
+}
+
+// average each channel of colors, ignoring transparent pixels
+func AverageColor(colors []color.NRGBA) color.NRGBA {
+	var r, g, b, a, n float64
+	for _, c := range colors {
+		if c.A == 0 {
+			continue
+		}
+		r, g, b, a = r+float64(c.R), g+float64(c.G), b+float64(c.B), a+float64(c.A)
+		n++
+	}
+	if n == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{R: uint8(math.Round(r / n)), G: uint8(math.Round(g / n)), B: uint8(math.Round(b / n)), A: uint8(math.Round(a / n))}
+}
+
+// the four tiles at the next zoom level covering t, in the order north west, north east, south west, south east
+func (t Tile) Children() [4]Tile {
+	z, x, y := t.Z+1, 2*t.X, 2*t.Y
+	return [4]Tile{{z, x, y}, {z, x + 1, y}, {z, x, y + 1}, {z, x + 1, y + 1}}
+}
+
+// build a tile from its four children by averaging each 2x2 block of pixels
+//
+// children are ordered as in Children. missing children are nil and their quarter is filled with the
+// average of no pixels, sea level for TileEncoding.Average and transparent for AverageColor
+func DownsampleTile(children [4]image.Image, average func(colors []color.NRGBA) color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, TileSize, TileSize))
+	block := make([]color.NRGBA, 4)
+	for i, child := range children {
+		ox, oy := (i%2)*TileSize/2, (i/2)*TileSize/2
+		if child == nil {
+			empty := average(nil)
+			for py := range TileSize / 2 {
+				for px := range TileSize / 2 {
+					img.SetNRGBA(ox+px, oy+py, empty)
+				}
+			}
+			continue
+		}
+		b := child.Bounds()
+		for py := range TileSize / 2 {
+			for px := range TileSize / 2 {
+				for k := range 4 {
+					x, y := b.Min.X+2*px+k%2, b.Min.Y+2*py+k/2
+					block[k] = color.NRGBAModel.Convert(child.At(x, y)).(color.NRGBA)
+				}
+				img.SetNRGBA(ox+px, oy+py, average(block))
+			}
+		}
+	}
+	return img
+}
+
+// write TileSize * TileSize elevations to w as an encoded png tile
+func ElevationTileToPNG(w io.Writer, elevs []float64, encoding TileEncoding) error {
+	colors := make([]color.NRGBA, len(elevs))
+	for i, v := range elevs {
+		c, err := encoding.Color(v)
+		if err != nil {
+			return err
+		}
+		colors[i] = c
+	}
+	return TileToPNG(w, colors)
+}
+
+// write TileSize * TileSize pixels, row by row from the north west corner, to w as a png tile
+func TileToPNG(w io.Writer, colors []color.NRGBA) error {
+	img := image.NewNRGBA(image.Rect(0, 0, TileSize, TileSize))
+	for i, c := range colors {
+		img.SetNRGBA(i%TileSize, i/TileSize, c)
+	}
+	return png.Encode(w, img)
+}
+
+// gray pixel for a hillshade value (0-255), transparent for voids
+func HillshadeColor(v float64) color.NRGBA {
+	if math.IsNaN(v) {
+		return color.NRGBA{}
+	}
+	y := uint8(math.Max(0, math.Min(255, v)))
+	return color.NRGBA{R: y, G: y, B: y, A: 255}
+}
+
+// darken c by a hillshade value (0-255)
+//
+// the shade is mapped to 0.5-1 so that shadows keep some of their color
+func ShadeColor(c color.NRGBA, shade float64) color.NRGBA {
+	if math.IsNaN(shade) {
+		return c
+	}
+	f := 0.5 + 0.5*math.Max(0, math.Min(255, shade))/255
+	return color.NRGBA{R: uint8(float64(c.R) * f), G: uint8(float64(c.G) * f), B: uint8(float64(c.B) * f), A: c.A}
+}
@@ -0,0 +1,39 @@
+package elevation
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDownsampleTileVoids(t *testing.T) {
+	// a child with an opaque north west block and a transparent rest
+	child := image.NewNRGBA(image.Rect(0, 0, TileSize, TileSize))
+	c, _ := TerrainRGB.Color(1000)
+	child.SetNRGBA(0, 0, c)
+	child.SetNRGBA(1, 1, c)
+	children := [4]image.Image{child}
+
+	seaLevel, _ := TerrainRGB.Color(0)
+	img := DownsampleTile(children, TerrainRGB.Average)
+	tests := []struct {
+		name string
+		x, y int
+		want color.NRGBA
+	}{
+		{"averaged block", 0, 0, c},
+		{"transparent block", 1, 0, seaLevel},
+		{"missing child", TileSize - 1, TileSize - 1, seaLevel},
+	}
+	for _, tt := range tests {
+		if got := img.NRGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("%s: pixel %d,%d = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// hillshade has no elevation to fall back to
+	img = DownsampleTile(children, AverageColor)
+	if got := img.NRGBAAt(TileSize-1, TileSize-1); got.A != 0 {
+		t.Errorf("missing hillshade child = %v, want transparent", got)
+	}
+}
@@ -0,0 +1,126 @@
+package elevation
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// values of a viewshed grid
+const (
+	Hidden  = 0.0
+	Visible = 1.0
+)
+
+// parameters for a viewshed
+type ViewshedOptions struct {
+	// height (m) of the observer above the ground
+	ObserverHeight float64
+	// height (m) above the ground a cell must be visible at
+	TargetHeight float64
+	// maximum distance (m) from the observer
+	Radius float64
+	// account for the curvature of the earth
+	Curvature bool
+	// coefficient of refraction. only used when Curvature is set
+	Refraction float64
+}
+
+// compute the area visible from observer using the R2 algorithm
+//
+// a ray is cast from the observer to every cell on the edge of the area and each
+// cell along the ray is compared against the highest elevation angle seen so far.
+// cells are Visible, Hidden, or NaN if they are voids or outside the radius
+func (g *Grid) Viewshed(observer Coordinate, opts ViewshedOptions) (*Grid, error) {
+	r0, c0 := g.Cell(observer.Latitude, observer.Longitude)
+	if !g.Valid(r0, c0) {
+		return nil, fmt.Errorf("no data at observer %f, %f", observer.Latitude, observer.Longitude)
+	}
+	z0 := g.At(r0, c0) + opts.ObserverHeight
+	dx, dy := g.CellSize(r0)
+	radius := EffectiveEarthRadius(opts.Refraction)
+
+	out := g.Like()
+	out.Set(r0, c0, Visible)
+
+	rows := int(math.Ceil(opts.Radius / dy))
+	cols := int(math.Ceil(opts.Radius / dx))
+	cast := func(rt int, ct int) {
+		dr, dc := rt-r0, ct-c0
+		steps := max(abs(dr), abs(dc))
+		maxAngle := math.Inf(-1)
+		for k := 1; k <= steps; k++ {
+			row := r0 + int(math.Round(float64(dr*k)/float64(steps)))
+			col := c0 + int(math.Round(float64(dc*k)/float64(steps)))
+			d := math.Hypot(float64(row-r0)*dy, float64(col-c0)*dx)
+			if d > opts.Radius {
+				return
+			}
+			z := g.At(row, col)
+			if math.IsNaN(z) {
+				continue
+			}
+			if opts.Curvature {
+				z -= d * d / (2 * radius)
+			}
+			if (z+opts.TargetHeight-z0)/d >= maxAngle {
+				out.Set(row, col, Visible)
+			} else if out.At(row, col) != Visible {
+				out.Set(row, col, Hidden)
+			}
+			maxAngle = math.Max(maxAngle, (z-z0)/d)
+		}
+	}
+	for c := c0 - cols; c <= c0+cols; c++ {
+		cast(r0-rows, c)
+		cast(r0+rows, c)
+	}
+	for r := r0 - rows + 1; r < r0+rows; r++ {
+		cast(r, c0-cols)
+		cast(r, c0+cols)
+	}
+	return out, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// convert a viewshed to GeoJSON polygons of the visible area
+func ViewshedToGeoJSON(viewshed *Grid) FeatureCollection {
+	polygons := viewshed.Polygonize(func(v float64) bool { return v == Visible })
+	features := make([]Feature, len(polygons))
+	for i, p := range polygons {
+		features[i] = NewFeature(NewPolygon(p), map[string]any{"visible": true})
+	}
+	return NewFeatureCollection(features)
+}
+
+// write the viewshed to w as a png
+//
+// visible cells are white, hidden cells are black, and everything else is transparent
+func ViewshedToPNG(w io.Writer, viewshed *Grid) error {
+	return maskToPNG(w, viewshed)
+}
+
+// write a grid of 0s and 1s to w as a black and white png. other values are transparent
+func maskToPNG(w io.Writer, g *Grid) error {
+	img := image.NewNRGBA(image.Rect(0, 0, g.Cols, g.Rows))
+	for row := range g.Rows {
+		for col := range g.Cols {
+			switch g.At(row, col) {
+			case 1:
+				img.SetNRGBA(col, row, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			case 0:
+				img.SetNRGBA(col, row, color.NRGBA{A: 255})
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
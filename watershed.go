@@ -0,0 +1,95 @@
+package elevation
+
+import (
+	"fmt"
+	"math"
+)
+
+// the catchment upstream of a pour point
+type Watershed struct {
+	// the pour point after snapping to the highest accumulation nearby
+	Pour Coordinate `json:"pour"`
+	// number of cells in the catchment
+	Cells int `json:"cells"`
+	// area in square meters
+	Area          float64 `json:"area"`
+	MeanElevation float64 `json:"mean_elevation"`
+	// length (m) of the longest flow path to the pour point
+	MaxFlowLength float64 `json:"max_flow_length"`
+	// true if the catchment reaches the edge of the grid, in which case it is likely incomplete
+	Truncated bool `json:"truncated"`
+	// 1 for cells in the catchment, NaN otherwise
+	Mask *Grid `json:"-"`
+}
+
+// delineate the catchment draining through pour
+//
+// the pour point is moved to the cell with the highest accumulation within snap cells
+func (h Hydrology) Watershed(pour Coordinate, snap int) (Watershed, error) {
+	dir, acc := h.Direction, h.Accumulation
+	row, col := dir.Cell(pour.Latitude, pour.Longitude)
+	if !dir.Valid(row, col) {
+		return Watershed{}, fmt.Errorf("no data at pour point %f, %f", pour.Latitude, pour.Longitude)
+	}
+	best := acc.At(row, col)
+	r0, c0 := row, col
+	for r := r0 - snap; r <= r0+snap; r++ {
+		for c := c0 - snap; c <= c0+snap; c++ {
+			if v := acc.At(r, c); v > best {
+				best, row, col = v, r, c
+			}
+		}
+	}
+
+	ws := Watershed{Pour: Coordinate{Latitude: dir.Latitude(row), Longitude: dir.Longitude(col)}, Mask: dir.Like()}
+	// walk upstream from the pour point, tracking the flow distance to it
+	type cell struct {
+		row  int
+		col  int
+		dist float64
+	}
+	queue := []cell{{row, col, 0}}
+	ws.Mask.Set(row, col, 1)
+	total := 0.0
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		dx, dy := dir.CellSize(c.row)
+		ws.Cells++
+		ws.Area += dx * dy
+		total += h.DEM.At(c.row, c.col)
+		ws.MaxFlowLength = math.Max(ws.MaxFlowLength, c.dist)
+		if c.row == 0 || c.col == 0 || c.row == dir.Rows-1 || c.col == dir.Cols-1 {
+			ws.Truncated = true
+		}
+		for _, d := range d8 {
+			r, cc := c.row+d.dr, c.col+d.dc
+			if ws.Mask.Valid(r, cc) {
+				continue
+			}
+			if dr, dc, ok := dir.downstream(r, cc); !ok || dr != c.row || dc != c.col {
+				continue
+			}
+			step := math.Hypot(float64(d.dc)*dx, float64(d.dr)*dy)
+			ws.Mask.Set(r, cc, 1)
+			queue = append(queue, cell{r, cc, c.dist + step})
+		}
+	}
+	ws.MeanElevation = total / float64(ws.Cells)
+	return ws, nil
+}
+
+// convert the watershed to a GeoJSON feature collection with a single MultiPolygon
+func WatershedToGeoJSON(ws Watershed) FeatureCollection {
+	polygons := ws.Mask.Polygonize(func(v float64) bool { return v == 1 })
+	feature := NewFeature(NewMultiPolygon(polygons), map[string]any{
+		"pour_latitude":   ws.Pour.Latitude,
+		"pour_longitude":  ws.Pour.Longitude,
+		"cells":           ws.Cells,
+		"area":            ws.Area,
+		"mean_elevation":  ws.MeanElevation,
+		"max_flow_length": ws.MaxFlowLength,
+		"truncated":       ws.Truncated,
+	})
+	return NewFeatureCollection([]Feature{feature})
+}